@@ -3,7 +3,6 @@ package main
 import (
 
 	//"os"
-	"fmt"
 	"runtime"
 	"time"
 
@@ -51,17 +50,35 @@ func main() {
 	ui.CreateRenderableBox(&layout, 800, 100, 2, 1, 1, 2, [4]float32{0, 0, 1, 1})
 	layout.Layout()
 
-	fnt, err := font.LoadFont("C:/Windows/Fonts/arial.ttf", 100.0)
+	dispatcher := ui.NewEventDispatcher(&layout)
+	attachEvents(window, &dispatcher)
+
+	fonts := font.NewFontManager()
+	err = fonts.RegisterDefaults()
+	if err != nil {
+		panic(err)
+	}
+	fnt, err := fonts.GetFont(font.DefaultFamily, font.Regular, 100.0)
 	if err != nil {
-		fmt.Printf(err.Error())
+		panic(err)
 	}
 
 	gl.ClearColor(0, 0, 0, 0)
 
+	renderer := ui.NewGLRenderer()
+
 	for !window.ShouldClose() {
 		// Do OpenGL stuff.
 		width, height := window.GetFramebufferSize()
 		gl.Viewport(0, 0, int32(width), int32(height))
+
+		// fonts are sized in points, so rescale them when the window moves to a monitor with a different pixel density
+		windowWidth, _ := window.GetSize()
+		if windowWidth > 0 {
+			if dpi := font.DefaultDPI * float64(width) / float64(windowWidth); dpi != fonts.GetDPI() {
+				fonts.SetDPI(dpi)
+			}
+		}
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		//gl.MatrixMode(gl.MODELVIEW)
@@ -88,18 +105,13 @@ func main() {
 		//gl.Vertex2d(0, 0.5)
 
 		//gl.End()
-		//layout.Render()
-
-		//vao := fnt.Glyphs[50].GLVAO
-		//gl.BindVertexArray(*vao)
-		//gl.DrawArrays(gl.LINES, 0, (*fnt).Glyphs[0].Count)
+		//layout.Render(renderer)
 
 		//drawString(0, 0, "test")
 		time := time.Now().Second()
-		gl.Color3d(1, 1, 1)
 
 		//var last *truetype.Point
-		font.DrawSlowString(fnt, "Testing", 100, 100+float64(time)*4)
+		renderer.DrawText(fnt, "Testing", 100, 100+float32(time)*4, [4]float32{1, 1, 1, 1})
 
 		window.SwapBuffers()
 		glfw.PollEvents()
@@ -107,6 +119,123 @@ func main() {
 
 }
 
+// attachEvents feeds the window's input to the dispatcher. The UI is laid out in framebuffer pixels, so
+// cursor positions are scaled from window coordinates to match on high density displays.
+func attachEvents(window *glfw.Window, dispatcher *ui.EventDispatcher) {
+	cursor := func() ui.Point {
+		x, y := window.GetCursorPos()
+		windowWidth, windowHeight := window.GetSize()
+		width, height := window.GetFramebufferSize()
+		if windowWidth > 0 && windowHeight > 0 {
+			x *= float64(width) / float64(windowWidth)
+			y *= float64(height) / float64(windowHeight)
+		}
+		return ui.NewPoint(float32(x), float32(y))
+	}
+	// mods remembers the modifiers from the last key event, since GLFW doesn't pass them to every callback
+	var mods ui.Modifiers
+
+	window.SetCursorPosCallback(func(w *glfw.Window, x float64, y float64) {
+		dispatcher.MoveMouse(cursor(), mods)
+	})
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, glfwMods glfw.ModifierKey) {
+		mods = toModifiers(glfwMods)
+		if action == glfw.Press {
+			dispatcher.PressMouse(cursor(), toMouseButton(button), mods)
+		} else if action == glfw.Release {
+			dispatcher.ReleaseMouse(cursor(), toMouseButton(button), mods)
+		}
+	})
+	window.SetScrollCallback(func(w *glfw.Window, x float64, y float64) {
+		dispatcher.ScrollMouse(cursor(), ui.NewPoint(float32(x), float32(y)), mods)
+	})
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, glfwMods glfw.ModifierKey) {
+		mods = toModifiers(glfwMods)
+		switch action {
+		case glfw.Press, glfw.Repeat:
+			dispatcher.PressKey(toKey(key), mods, action == glfw.Repeat)
+		case glfw.Release:
+			dispatcher.ReleaseKey(toKey(key), mods)
+		}
+	})
+	window.SetCharCallback(func(w *glfw.Window, char rune) {
+		dispatcher.TypeText(string(char))
+	})
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width int, height int) {
+		dispatcher.Resize(float32(width), float32(height))
+	})
+	width, height := window.GetFramebufferSize()
+	dispatcher.Resize(float32(width), float32(height))
+}
+
+// toKey converts a GLFW key to the key components respond to
+func toKey(key glfw.Key) ui.Key {
+	switch key {
+	case glfw.KeyLeft:
+		return ui.KeyLeft
+	case glfw.KeyRight:
+		return ui.KeyRight
+	case glfw.KeyUp:
+		return ui.KeyUp
+	case glfw.KeyDown:
+		return ui.KeyDown
+	case glfw.KeyHome:
+		return ui.KeyHome
+	case glfw.KeyEnd:
+		return ui.KeyEnd
+	case glfw.KeyPageUp:
+		return ui.KeyPageUp
+	case glfw.KeyPageDown:
+		return ui.KeyPageDown
+	case glfw.KeyBackspace:
+		return ui.KeyBackspace
+	case glfw.KeyDelete:
+		return ui.KeyDelete
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		return ui.KeyEnter
+	case glfw.KeyTab:
+		return ui.KeyTab
+	case glfw.KeyA:
+		return ui.KeyA
+	case glfw.KeyC:
+		return ui.KeyC
+	case glfw.KeyV:
+		return ui.KeyV
+	case glfw.KeyX:
+		return ui.KeyX
+	}
+	return ui.KeyUnknown
+}
+
+// toModifiers converts GLFW modifier keys to the modifiers components respond to
+func toModifiers(mods glfw.ModifierKey) ui.Modifiers {
+	var modifiers ui.Modifiers
+	if mods&glfw.ModShift != 0 {
+		modifiers |= ui.ModShift
+	}
+	if mods&glfw.ModControl != 0 {
+		modifiers |= ui.ModControl
+	}
+	if mods&glfw.ModAlt != 0 {
+		modifiers |= ui.ModAlt
+	}
+	if mods&glfw.ModSuper != 0 {
+		modifiers |= ui.ModSuper
+	}
+	return modifiers
+}
+
+// toMouseButton converts a GLFW mouse button to the buttons components respond to
+func toMouseButton(button glfw.MouseButton) ui.MouseButton {
+	switch button {
+	case glfw.MouseButtonRight:
+		return ui.MouseRight
+	case glfw.MouseButtonMiddle:
+		return ui.MouseMiddle
+	}
+	return ui.MouseLeft
+}
+
 /*func drawString(x, y float32, str string) error {
 	//for i := range fonts {
 
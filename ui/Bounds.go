@@ -24,3 +24,9 @@ func NewBounds(x float32, y float32, width float32, height float32) Bounds {
 func (bounds Bounds) String() string {
 	return fmt.Sprintf("(%f, %f) - <%f, %f>", bounds.X, bounds.Y, bounds.Width, bounds.Height)
 }
+
+// Contains determines whether a point is inside the bounds, counting the top and left edges but not the
+// bottom and right edges so that neighbouring bounds never both contain a point
+func (bounds Bounds) Contains(point Point) bool {
+	return point.X >= bounds.X && point.Y >= bounds.Y && point.X < bounds.X+bounds.Width && point.Y < bounds.Y+bounds.Height
+}
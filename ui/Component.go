@@ -5,5 +5,94 @@ type Component interface {
 	GetBounds() Bounds
 	SetBounds(bounds Bounds)
 	GetMinimumSize() Bounds
-	Render()
+	Render(renderer Renderer)
+}
+
+// Container is a component with other components inside it, which events travel through on their way to
+// the components they were sent to. Children are placed by their bounds relative to the top left corner of
+// the container, and the ones later in the list are drawn on top.
+type Container interface {
+	Component
+	GetChildren() []Component
+}
+
+// lazyContainer is a container that places its children when it is next drawn, which has to happen before
+// anything can find them by their bounds
+type lazyContainer interface {
+	Container
+	layOutIfNeeded()
+}
+
+// ComponentAt finds the deepest component under a point relative to the top left corner of root, looking
+// inside containers through the children placed under the point, and gets the point relative to the top
+// left corner of the component it finds. It finds root itself when no child is under the point.
+func ComponentAt(root Component, point Point) (Component, Point) {
+	path := componentPathAt(root, point)
+	hit := path[len(path)-1]
+	return hit.component, NewPoint(point.X-hit.origin.X, point.Y-hit.origin.Y)
+}
+
+// componentHit is a component on the way down the tree to a point, along with where its top left corner is
+// relative to the root
+type componentHit struct {
+	component Component
+	origin    Point
+}
+
+// componentPathAt finds the components under a point relative to the root, from the root down to the
+// deepest one. The root is always included, and containers are searched from the children drawn on top.
+func componentPathAt(root Component, point Point) []componentHit {
+	path := []componentHit{{component: root}}
+	for {
+		container, ok := path[len(path)-1].component.(Container)
+		if !ok {
+			return path
+		}
+		if lazy, ok := container.(lazyContainer); ok {
+			lazy.layOutIfNeeded()
+		}
+		origin := path[len(path)-1].origin
+		local := NewPoint(point.X-origin.X, point.Y-origin.Y)
+		children := container.GetChildren()
+		found := false
+		for i := len(children) - 1; i >= 0 && !found; i-- {
+			bounds := children[i].GetBounds()
+			if bounds.Contains(local) {
+				path = append(path, componentHit{children[i], NewPoint(origin.X+bounds.X, origin.Y+bounds.Y)})
+				found = true
+			}
+		}
+		if !found {
+			return path
+		}
+	}
+}
+
+// componentPathTo finds the components from the root down to a component, or nil if the component isn't in
+// the tree anymore
+func componentPathTo(root Component, target Component) []componentHit {
+	if target == nil {
+		return nil
+	}
+	if root == target {
+		return []componentHit{{component: root}}
+	}
+	container, ok := root.(Container)
+	if !ok {
+		return nil
+	}
+	if lazy, ok := container.(lazyContainer); ok {
+		lazy.layOutIfNeeded()
+	}
+	for _, child := range container.GetChildren() {
+		if path := componentPathTo(child, target); path != nil {
+			bounds := child.GetBounds()
+			for i := range path {
+				path[i].origin.X += bounds.X
+				path[i].origin.Y += bounds.Y
+			}
+			return append([]componentHit{{component: root}}, path...)
+		}
+	}
+	return nil
 }
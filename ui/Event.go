@@ -0,0 +1,87 @@
+package ui
+
+// EventType is the kind of input an event carries
+type EventType int
+
+const (
+	// MouseMoveEvent is sent when the mouse moves, to the component under it or to the component a button was
+	// pressed on while the button is held
+	MouseMoveEvent EventType = iota
+	// MouseDownEvent and MouseUpEvent are sent when a mouse button is pressed and released
+	MouseDownEvent
+	MouseUpEvent
+	// MouseScrollEvent is sent when the mouse wheel or a touchpad scrolls, to the component under the mouse
+	MouseScrollEvent
+	// KeyDownEvent and KeyUpEvent are sent to the focused component when a key is pressed and released, with
+	// KeyDownEvent sent again while the key is held down
+	KeyDownEvent
+	KeyUpEvent
+	// TextInputEvent is sent to the focused component with text that has been typed
+	TextInputEvent
+	// ResizeEvent is sent to the root component when the window changes size, after the root has been resized
+	ResizeEvent
+)
+
+// EventPhase is which part of its trip through the component tree an event is on
+type EventPhase int
+
+const (
+	// PhaseCapture is when an event visits the containers above its target, from the root down, so a
+	// container can see events before the components inside it do
+	PhaseCapture EventPhase = iota
+	// PhaseTarget is when an event visits the component it was sent to
+	PhaseTarget
+	// PhaseBubble is when an event visits the containers above its target again, back up to the root
+	PhaseBubble
+)
+
+// MouseButton is a set of mouse buttons
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = 1 << iota
+	MouseRight
+	MouseMiddle
+)
+
+// Event is a piece of input from the mouse, the keyboard or the window, along with where it is in its trip
+// through the component tree
+type Event struct {
+	Type  EventType
+	Phase EventPhase
+	// Target is the component the event was sent to, and Current is the component handling it right now
+	Target  Component
+	Current Component
+	// Point is where the mouse is, relative to the top left corner of Current
+	Point Point
+	// Button is the button that was pressed or released, and Buttons are all of the buttons held down
+	Button  MouseButton
+	Buttons MouseButton
+	// Scroll is how far to scroll in lines, which is positive up and to the right like GLFW reports it
+	Scroll Point
+	Key    Key
+	Mods   Modifiers
+	// Repeat is set on key presses that come from a key being held down
+	Repeat bool
+	Text   string
+	// Size is the new size of the window for resize events
+	Size    Bounds
+	stopped bool
+}
+
+// StopPropagation stops the event from visiting any more components, which handlers do once they have
+// used the event
+func (event *Event) StopPropagation() {
+	event.stopped = true
+}
+
+// IsStopped determines whether a component has stopped the event from going any further
+func (event Event) IsStopped() bool {
+	return event.stopped
+}
+
+// EventHandler is a component that responds to input. Components that don't implement it are passed over
+// as events travel through the tree.
+type EventHandler interface {
+	HandleEvent(event *Event)
+}
@@ -0,0 +1,152 @@
+package ui
+
+// EventDispatcher sends input to the components in a tree. Mouse events go to the deepest component under
+// the mouse, or to the component a button was pressed on until every button is released, so dragging
+// keeps working outside of it. Keyboard events go to the focused component, and pressing on a focusable
+// component or tabbing to it focuses it.
+type EventDispatcher struct {
+	// Focus decides which component keyboard events are sent to, which is the root when nothing is focused.
+	// Its root is the root events are dispatched through, so tab order and focus rings follow the same tree.
+	Focus *FocusManager
+	// pressed is the component mouse events are sent to while buttons are held
+	pressed Component
+	buttons MouseButton
+}
+
+// NewEventDispatcher creates a new event dispatcher that sends events to the components under root
+func NewEventDispatcher(root Component) EventDispatcher {
+	focus := NewFocusManager(root)
+	return EventDispatcher{
+		Focus: &focus,
+	}
+}
+
+// GetRoot gets the component at the top of the tree events are sent through
+func (dispatcher EventDispatcher) GetRoot() Component {
+	return dispatcher.Focus.Root
+}
+
+// SetRoot changes the component at the top of the tree events are sent through, which is also the tree
+// focus moves through
+func (dispatcher *EventDispatcher) SetRoot(root Component) {
+	dispatcher.Focus.Root = root
+	dispatcher.pressed = nil
+	dispatcher.buttons = 0
+}
+
+// MoveMouse sends a mouse move event for the mouse moving to a point in the window
+func (dispatcher *EventDispatcher) MoveMouse(point Point, mods Modifiers) bool {
+	return dispatcher.Dispatch(Event{Type: MouseMoveEvent, Point: point, Mods: mods})
+}
+
+// PressMouse sends a mouse down event for a button being pressed at a point in the window
+func (dispatcher *EventDispatcher) PressMouse(point Point, button MouseButton, mods Modifiers) bool {
+	return dispatcher.Dispatch(Event{Type: MouseDownEvent, Point: point, Button: button, Mods: mods})
+}
+
+// ReleaseMouse sends a mouse up event for a button being released at a point in the window
+func (dispatcher *EventDispatcher) ReleaseMouse(point Point, button MouseButton, mods Modifiers) bool {
+	return dispatcher.Dispatch(Event{Type: MouseUpEvent, Point: point, Button: button, Mods: mods})
+}
+
+// ScrollMouse sends a scroll event for scrolling by a number of lines with the mouse at a point in the window
+func (dispatcher *EventDispatcher) ScrollMouse(point Point, scroll Point, mods Modifiers) bool {
+	return dispatcher.Dispatch(Event{Type: MouseScrollEvent, Point: point, Scroll: scroll, Mods: mods})
+}
+
+// PressKey sends a key down event, with repeat set when it comes from the key being held down
+func (dispatcher *EventDispatcher) PressKey(key Key, mods Modifiers, repeat bool) bool {
+	return dispatcher.Dispatch(Event{Type: KeyDownEvent, Key: key, Mods: mods, Repeat: repeat})
+}
+
+// ReleaseKey sends a key up event
+func (dispatcher *EventDispatcher) ReleaseKey(key Key, mods Modifiers) bool {
+	return dispatcher.Dispatch(Event{Type: KeyUpEvent, Key: key, Mods: mods})
+}
+
+// TypeText sends a text input event for text that has been typed
+func (dispatcher *EventDispatcher) TypeText(text string) bool {
+	return dispatcher.Dispatch(Event{Type: TextInputEvent, Text: text})
+}
+
+// Resize resizes the root to fill the window and sends it a resize event
+func (dispatcher *EventDispatcher) Resize(width float32, height float32) bool {
+	return dispatcher.Dispatch(Event{Type: ResizeEvent, Size: NewBounds(0, 0, width, height)})
+}
+
+// Dispatch sends an event through the components it belongs to, with mouse positions given in window
+// coordinates. It visits each container above the target from the root down, then the target, then the
+// containers again from the target up, and returns whether a component stopped the event along the way.
+// Tab presses that no component stops move focus to the next component, or the previous one with shift,
+// and count as stopped when focus moves.
+func (dispatcher *EventDispatcher) Dispatch(event Event) bool {
+	root := dispatcher.Focus.Root
+	if root == nil {
+		return false
+	}
+	var path []componentHit
+	switch event.Type {
+	case MouseMoveEvent, MouseDownEvent, MouseUpEvent, MouseScrollEvent:
+		if dispatcher.buttons != 0 {
+			path = componentPathTo(root, dispatcher.pressed)
+		}
+		if path == nil {
+			path = componentPathAt(root, event.Point)
+		}
+		target := path[len(path)-1].component
+		switch event.Type {
+		case MouseDownEvent:
+			if dispatcher.buttons == 0 {
+				dispatcher.pressed = target
+			}
+			dispatcher.buttons |= event.Button
+			dispatcher.Focus.SetFocus(focusableOn(path))
+		case MouseUpEvent:
+			dispatcher.buttons &^= event.Button
+		}
+		event.Buttons = dispatcher.buttons
+	case ResizeEvent:
+		root.SetBounds(event.Size)
+		path = []componentHit{{component: root}}
+	default:
+		path = componentPathTo(root, dispatcher.Focus.GetFocused())
+		if path == nil {
+			path = []componentHit{{component: root}}
+		}
+	}
+
+	window := event.Point
+	event.Target = path[len(path)-1].component
+	visit := func(hit componentHit, phase EventPhase) {
+		if handler, ok := hit.component.(EventHandler); ok && !event.stopped {
+			event.Phase = phase
+			event.Current = hit.component
+			event.Point = NewPoint(window.X-hit.origin.X, window.Y-hit.origin.Y)
+			handler.HandleEvent(&event)
+		}
+	}
+	for _, hit := range path[:len(path)-1] {
+		visit(hit, PhaseCapture)
+	}
+	visit(path[len(path)-1], PhaseTarget)
+	for i := len(path) - 2; i >= 0; i-- {
+		visit(path[i], PhaseBubble)
+	}
+	if event.Type == KeyDownEvent && event.Key == KeyTab && !event.stopped {
+		if event.Mods&ModShift != 0 {
+			return dispatcher.Focus.FocusPrevious()
+		}
+		return dispatcher.Focus.FocusNext()
+	}
+	return event.stopped
+}
+
+// focusableOn finds the deepest focusable component on a path, or nil if there isn't one
+func focusableOn(path []componentHit) Component {
+	for i := len(path) - 1; i >= 0; i-- {
+		if _, ok := path[i].component.(Focusable); ok {
+			return path[i].component
+		}
+	}
+	return nil
+}
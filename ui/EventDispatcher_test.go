@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingLayout struct {
+	TableLayout
+	name string
+	log  *[]string
+	stop EventPhase
+}
+
+func (layout *recordingLayout) HandleEvent(event *Event) {
+	*layout.log = append(*layout.log, fmt.Sprintf("%s %d %v", layout.name, event.Phase, event.Point))
+	if event.Phase == layout.stop {
+		event.StopPropagation()
+	}
+}
+
+type recordingBox struct {
+	Box
+	log *[]string
+}
+
+func (box *recordingBox) HandleEvent(event *Event) {
+	*box.log = append(*box.log, fmt.Sprintf("box %d %v %d", event.Phase, event.Point, event.Buttons))
+}
+
+func (box *recordingBox) SetFocused(focused bool) {
+}
+
+func CheckLog(t *testing.T, log *[]string, expected ...string) {
+	t.Helper()
+	if fmt.Sprint(*log) != fmt.Sprint(expected) {
+		t.Errorf("Invalid events: expected %v, got %v", expected, *log)
+	}
+	*log = nil
+}
+
+func createRecordingTree(log *[]string) (*recordingLayout, *recordingLayout, *recordingBox) {
+	outer := &recordingLayout{TableLayout: NewTableLayout(), name: "outer", log: log, stop: -1}
+	inner := &recordingLayout{TableLayout: NewTableLayout(), name: "inner", log: log, stop: -1}
+	box := &recordingBox{Box: NewBox(20, 10), log: log}
+	CreateBox(&outer.TableLayout, 30, 20, 0, 0, 1, 1)
+	outer.Add(inner, 1, 1, 1, 1)
+	CreateBox(&inner.TableLayout, 5, 5, 0, 0, 1, 1)
+	inner.Add(box, 1, 1, 1, 1)
+	outer.Layout()
+	inner.SetBounds(NewBounds(30, 20, 40, 30))
+	return outer, inner, box
+}
+
+func TestEventDispatchPhases(t *testing.T) {
+	var log []string
+	outer, inner, _ := createRecordingTree(&log)
+	dispatcher := NewEventDispatcher(outer)
+	dispatcher.MoveMouse(NewPoint(40, 30), 0)
+	CheckLog(t, &log,
+		"outer 0 (40.000000, 30.000000)",
+		"inner 0 (10.000000, 10.000000)",
+		"box 1 (5.000000, 5.000000) 0",
+		"inner 2 (10.000000, 10.000000)",
+		"outer 2 (40.000000, 30.000000)")
+
+	// points outside of every child stop at the deepest container under them
+	dispatcher.MoveMouse(NewPoint(65, 45), 0)
+	CheckLog(t, &log,
+		"outer 0 (65.000000, 45.000000)",
+		"inner 1 (35.000000, 25.000000)",
+		"outer 2 (65.000000, 45.000000)")
+
+	inner.stop = PhaseCapture
+	if !dispatcher.MoveMouse(NewPoint(40, 30), 0) {
+		t.Error("Expected the event to be stopped")
+	}
+	CheckLog(t, &log,
+		"outer 0 (40.000000, 30.000000)",
+		"inner 0 (10.000000, 10.000000)")
+}
+
+func TestEventDispatchCapture(t *testing.T) {
+	var log []string
+	outer, _, _ := createRecordingTree(&log)
+	dispatcher := NewEventDispatcher(outer)
+	dispatcher.PressMouse(NewPoint(40, 30), MouseLeft, 0)
+	log = nil
+	// dragging keeps sending events to the box after the mouse leaves it, until the button is released
+	dispatcher.MoveMouse(NewPoint(0, 0), 0)
+	CheckLog(t, &log,
+		"outer 0 (0.000000, 0.000000)",
+		"inner 0 (-30.000000, -20.000000)",
+		"box 1 (-35.000000, -25.000000) 1",
+		"inner 2 (-30.000000, -20.000000)",
+		"outer 2 (0.000000, 0.000000)")
+	dispatcher.ReleaseMouse(NewPoint(0, 0), MouseLeft, 0)
+	log = nil
+	dispatcher.MoveMouse(NewPoint(0, 0), 0)
+	CheckLog(t, &log, "outer 0 (0.000000, 0.000000)", "outer 2 (0.000000, 0.000000)")
+
+	// keyboard events go to the focusable component that was pressed on, until something else is pressed
+	dispatcher.PressKey(KeyA, 0, false)
+	CheckLog(t, &log,
+		"outer 0 (0.000000, 0.000000)",
+		"inner 0 (-30.000000, -20.000000)",
+		"box 1 (-35.000000, -25.000000) 0",
+		"inner 2 (-30.000000, -20.000000)",
+		"outer 2 (0.000000, 0.000000)")
+	dispatcher.PressMouse(NewPoint(100, 100), MouseRight, 0)
+	log = nil
+	dispatcher.TypeText("a")
+	CheckLog(t, &log, "outer 1 (0.000000, 0.000000)")
+}
+
+func TestEventDispatchLayout(t *testing.T) {
+	var log []string
+	layout := NewTableLayout()
+	CreateBox(&layout, 20, 20, 0, 0, 1, 1)
+	dispatcher := NewEventDispatcher(&layout)
+	dispatcher.Resize(100, 20)
+	// children added since the last layout are placed before the mouse looks for them
+	box := &recordingBox{Box: NewBox(20, 20), log: &log}
+	layout.Add(box, 0, 1, 1, 1)
+	dispatcher.PressMouse(NewPoint(30, 10), MouseLeft, 0)
+	if box.GetBounds().Width == 0 || len(log) != 1 {
+		t.Errorf("Expected the press to reach the new box, got %v", log)
+	}
+}
+
+func TestEventDispatchTextField(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	layout := NewTableLayout()
+	CreateBox(&layout, 50, 24, 0, 0, 1, 1)
+	field := CreateTextField(&layout, fnt, [4]float32{1, 1, 1, 1}, 0, 1, 1, 1)
+	dispatcher := NewEventDispatcher(&layout)
+	dispatcher.Resize(300, 24)
+	if layout.Bounds != NewBounds(0, 0, 300, 24) {
+		t.Errorf("Expected the root to fill the window, got %v", layout.Bounds)
+	}
+	dispatcher.PressMouse(NewPoint(60, 10), MouseLeft, 0)
+	dispatcher.ReleaseMouse(NewPoint(60, 10), MouseLeft, 0)
+	dispatcher.TypeText("Phobos")
+	if !dispatcher.PressKey(KeyLeft, ModShift, false) || dispatcher.PressKey(KeyUnknown, 0, false) {
+		t.Error("Expected the field to use only the keys it responds to")
+	}
+	CheckField(t, field, "Phobos", 5, 6)
+	x := float32(50) + field.Padding + float32(fnt.GetAdvance("Ph"))
+	dispatcher.PressMouse(NewPoint(x, 10), MouseLeft, 0)
+	dispatcher.MoveMouse(NewPoint(0, 10), 0)
+	CheckField(t, field, "Phobos", 0, 2)
+}
@@ -0,0 +1,148 @@
+package ui
+
+// Focusable is a component that can take keyboard focus, and is told when it gains or loses it so it can
+// show whether it has it, like a text field only drawing its caret while focused
+type Focusable interface {
+	Component
+	SetFocused(focused bool)
+}
+
+// FocusContainer is a container that decides the order focus moves through its children in, rather than
+// moving through them in the order they are drawn
+type FocusContainer interface {
+	Container
+	GetFocusOrder() []Component
+}
+
+// FocusManager keeps track of which component has keyboard focus, and moves focus between the focusable
+// components in a tree with tab and shift tab
+type FocusManager struct {
+	Root Component
+	// Order is the order tab moves focus through components in, or nil to go by where components are in
+	// the tree, which for table layouts is row by row from left to right
+	Order []Component
+	// OnFocusChanged is called with the component that lost focus and the component that gained it, either
+	// of which can be nil, if it is set
+	OnFocusChanged func(previous Component, current Component)
+	// RingColor and RingWidth are the color and thickness of the ring drawn around the focused component
+	RingColor [4]float32
+	RingWidth float32
+	focused   Component
+}
+
+// NewFocusManager creates a new focus manager for the components under root, with nothing focused
+func NewFocusManager(root Component) FocusManager {
+	return FocusManager{
+		Root:      root,
+		RingColor: [4]float32{0.3, 0.6, 1, 1},
+		RingWidth: 2,
+	}
+}
+
+// GetFocused gets the component with keyboard focus, or nil if nothing has it
+func (manager FocusManager) GetFocused() Component {
+	return manager.focused
+}
+
+// SetFocus gives keyboard focus to a component, or takes it away from everything when component is nil
+func (manager *FocusManager) SetFocus(component Component) {
+	previous := manager.focused
+	if previous == component {
+		return
+	}
+	manager.focused = component
+	if focusable, ok := previous.(Focusable); ok {
+		focusable.SetFocused(false)
+	}
+	if focusable, ok := component.(Focusable); ok {
+		focusable.SetFocused(true)
+	}
+	if manager.OnFocusChanged != nil {
+		manager.OnFocusChanged(previous, component)
+	}
+}
+
+// GetFocusOrder gets the components tab moves focus through, in order
+func (manager FocusManager) GetFocusOrder() []Component {
+	if manager.Order != nil {
+		return manager.Order
+	}
+	return focusOrder(manager.Root)
+}
+
+// FocusNext moves focus to the component after the focused one, wrapping around to the first, and returns
+// whether focus changed
+func (manager *FocusManager) FocusNext() bool {
+	return manager.moveFocus(1)
+}
+
+// FocusPrevious moves focus to the component before the focused one, wrapping around to the last, and
+// returns whether focus changed
+func (manager *FocusManager) FocusPrevious() bool {
+	return manager.moveFocus(-1)
+}
+
+// moveFocus moves focus forwards or backwards through the focus order, and returns whether focus changed.
+// When nothing in the order is focused, moving forwards focuses the first component and moving backwards
+// focuses the last.
+func (manager *FocusManager) moveFocus(direction int) bool {
+	order := manager.GetFocusOrder()
+	if len(order) == 0 {
+		return false
+	}
+	next := 0
+	if direction < 0 {
+		next = len(order) - 1
+	}
+	for i, component := range order {
+		if component == manager.focused {
+			next = (i + direction + len(order)) % len(order)
+			break
+		}
+	}
+	if order[next] == manager.focused {
+		return false
+	}
+	manager.SetFocus(order[next])
+	return true
+}
+
+// RenderFocusRing draws a ring just outside the bounds of the focused component, and is meant to be
+// called after the root has been rendered so the ring is drawn on top of everything
+func (manager FocusManager) RenderFocusRing(renderer Renderer) {
+	if manager.RingWidth <= 0 {
+		return
+	}
+	path := componentPathTo(manager.Root, manager.focused)
+	if path == nil {
+		return
+	}
+	hit := path[len(path)-1]
+	bounds := hit.component.GetBounds()
+	w := manager.RingWidth
+	x, y := hit.origin.X-w, hit.origin.Y-w
+	width, height := bounds.Width+2*w, bounds.Height+2*w
+	renderer.FillRect(NewBounds(x, y, width, w), manager.RingColor)
+	renderer.FillRect(NewBounds(x, y+height-w, width, w), manager.RingColor)
+	renderer.FillRect(NewBounds(x, y+w, w, height-2*w), manager.RingColor)
+	renderer.FillRect(NewBounds(x+width-w, y+w, w, height-2*w), manager.RingColor)
+}
+
+// focusOrder finds the focusable components in a tree in the order focus moves through them, with each
+// container's children coming straight after the container
+func focusOrder(component Component) []Component {
+	var order []Component
+	if _, ok := component.(Focusable); ok {
+		order = append(order, component)
+	}
+	var children []Component
+	if container, ok := component.(FocusContainer); ok {
+		children = container.GetFocusOrder()
+	} else if container, ok := component.(Container); ok {
+		children = container.GetChildren()
+	}
+	for _, child := range children {
+		order = append(order, focusOrder(child)...)
+	}
+	return order
+}
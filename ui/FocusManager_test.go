@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"testing"
+)
+
+type focusBox struct {
+	Box
+	focused bool
+}
+
+func (box *focusBox) SetFocused(focused bool) {
+	box.focused = focused
+}
+
+func createFocusBox(layout *TableLayout, row int, col int) *focusBox {
+	box := &focusBox{Box: NewBox(10, 10)}
+	layout.Add(box, row, col, 1, 1)
+	return box
+}
+
+func TestFocusOrder(t *testing.T) {
+	layout := NewTableLayout()
+	c := createFocusBox(&layout, 1, 0)
+	b := createFocusBox(&layout, 0, 1)
+	CreateBox(&layout, 10, 10, 0, 2, 1, 1)
+	a := createFocusBox(&layout, 0, 0)
+	layout.Layout()
+	manager := NewFocusManager(&layout)
+	var changes [][2]Component
+	manager.OnFocusChanged = func(previous Component, current Component) {
+		changes = append(changes, [2]Component{previous, current})
+	}
+
+	// focus goes row by row from left to right, skipping components that can't be focused
+	for _, expected := range []*focusBox{a, b, c, a} {
+		manager.FocusNext()
+		if manager.GetFocused() != expected {
+			t.Errorf("Expected %v to be focused, got %v", expected, manager.GetFocused())
+		}
+	}
+	manager.FocusPrevious()
+	if manager.GetFocused() != c || !c.focused || a.focused {
+		t.Errorf("Expected focus to move back to c, got %v", manager.GetFocused())
+	}
+	if len(changes) != 5 || changes[4] != [2]Component{a, c} {
+		t.Errorf("Invalid focus changes: %v", changes)
+	}
+	manager.SetFocus(nil)
+	if c.focused || len(changes) != 6 || changes[5] != [2]Component{c, nil} {
+		t.Errorf("Expected focus to be cleared, got %v", changes)
+	}
+
+	manager.Order = []Component{c, a}
+	manager.FocusPrevious()
+	manager.FocusPrevious()
+	if manager.GetFocused() != c {
+		t.Errorf("Expected the overridden order to be used, got %v", manager.GetFocused())
+	}
+}
+
+func TestFocusDispatch(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	layout := NewTableLayout()
+	first := CreateTextField(&layout, fnt, [4]float32{1, 1, 1, 1}, 0, 0, 1, 1)
+	second := CreateTextArea(&layout, fnt, [4]float32{1, 1, 1, 1}, 1, 0, 1, 1)
+	dispatcher := NewEventDispatcher(&layout)
+	dispatcher.Resize(300, 300)
+
+	if !dispatcher.PressKey(KeyTab, 0, false) || dispatcher.Focus.GetFocused() != first {
+		t.Errorf("Expected tab to focus the field, got %v", dispatcher.Focus.GetFocused())
+	}
+	dispatcher.TypeText("Deimos")
+	dispatcher.PressKey(KeyTab, 0, false)
+	dispatcher.TypeText("Phobos")
+	if first.GetText() != "Deimos" || second.GetText() != "Phobos" {
+		t.Errorf("Expected text to go to the focused component, got %q and %q", first.GetText(), second.GetText())
+	}
+	dispatcher.PressKey(KeyTab, ModShift, false)
+	if dispatcher.Focus.GetFocused() != first || !first.focused || second.focused {
+		t.Errorf("Expected shift tab to focus the field again, got %v", dispatcher.Focus.GetFocused())
+	}
+
+	// pressing on a component that can't be focused takes focus away
+	dispatcher.PressMouse(NewPoint(299, 299), MouseLeft, 0)
+	if dispatcher.Focus.GetFocused() != nil || first.focused {
+		t.Errorf("Expected nothing to be focused, got %v", dispatcher.Focus.GetFocused())
+	}
+	dispatcher.ReleaseMouse(NewPoint(299, 299), MouseLeft, 0)
+	bounds := second.GetBounds()
+	dispatcher.PressMouse(NewPoint(bounds.X+5, bounds.Y+5), MouseLeft, 0)
+	if dispatcher.Focus.GetFocused() != second {
+		t.Errorf("Expected pressing on the area to focus it, got %v", dispatcher.Focus.GetFocused())
+	}
+}
+
+type focusRingComponent struct {
+	*TableLayout
+	focus *FocusManager
+}
+
+func (component focusRingComponent) Render(renderer Renderer) {
+	component.TableLayout.Render(renderer)
+	component.focus.RenderFocusRing(renderer)
+}
+
+func TestGoldenFocusRing(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	layout := NewTableLayout()
+	first := CreateTextField(&layout, fnt, [4]float32{1, 1, 1, 1}, 0, 0, 1, 1)
+	second := CreateTextField(&layout, fnt, [4]float32{1, 1, 1, 1}, 1, 0, 1, 1)
+	first.Columns, second.Columns = 10, 10
+	first.SetText("Ares")
+	second.SetText("Vallis")
+	layout.SetBounds(NewBounds(0, 0, 100, 60))
+	manager := NewFocusManager(&layout)
+	manager.FocusNext()
+	manager.FocusNext()
+	CheckGolden(t, "focus_ring", focusRingComponent{&layout, &manager}, 100, 60, 8)
+}
+
+func TestFocusDispatchRoot(t *testing.T) {
+	empty := NewTableLayout()
+	CreateBox(&empty, 10, 10, 0, 0, 1, 1)
+	dispatcher := NewEventDispatcher(&empty)
+	if dispatcher.PressKey(KeyTab, 0, false) {
+		t.Error("Expected tab not to be used when there is nothing to focus")
+	}
+
+	layout := NewTableLayout()
+	box := createFocusBox(&layout, 0, 0)
+	dispatcher.SetRoot(&layout)
+	if !dispatcher.PressKey(KeyTab, 0, false) || dispatcher.Focus.GetFocused() != box {
+		t.Errorf("Expected tab to focus through the new root, got %v", dispatcher.Focus.GetFocused())
+	}
+	if dispatcher.PressKey(KeyTab, 0, false) {
+		t.Error("Expected tab not to be used when focus can't move anywhere else")
+	}
+}
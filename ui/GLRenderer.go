@@ -0,0 +1,396 @@
+package ui
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strings"
+
+	"./font"
+
+	"github.com/go-gl/gl/all-core/gl"
+)
+
+// GLRenderer is a renderer that draws onto the active OpenGL context using immediate mode
+type GLRenderer struct {
+	transforms []Transform
+	// clips are the areas of the viewport drawing is restricted to, with the current clip last
+	clips         []image.Rectangle
+	textures      map[*font.AtlasPage]*glTexture
+	images        map[image.Image]uint32
+	distanceField *distanceFieldProgram
+	// shaderErr is why the distance field program couldn't be compiled, if it couldn't
+	shaderErr error
+}
+
+// glTexture tracks which version of an atlas page has been uploaded to a texture
+type glTexture struct {
+	id      uint32
+	version int
+}
+
+// distanceFieldProgram is the shader program that draws glyphs from distance fields, along with the
+// locations of its uniforms
+type distanceFieldProgram struct {
+	id      uint32
+	color   int32
+	spread  int32
+	offset  int32
+	falloff int32
+}
+
+// distanceFieldShader shades one layer of glyphs from distance fields, or from anti-aliased glyphs when
+// the spread is 0
+const distanceFieldShader = `
+#version 120
+uniform sampler2D page;
+uniform vec4 color;
+uniform float spread;
+uniform float offset;
+uniform float falloff;
+void main() {
+	float value = texture2D(page, gl_TexCoord[0].st).a;
+	float coverage = value;
+	if (spread > 0.0) {
+		float distance = (value - 0.5) * 2.0 * spread + offset;
+		if (falloff > 0.0) {
+			coverage = clamp(1.0 + distance / falloff, 0.0, 1.0);
+		} else {
+			float smoothing = max(0.5 * fwidth(distance), 0.0001);
+			coverage = smoothstep(-smoothing, smoothing, distance);
+		}
+	}
+	gl_FragColor = vec4(color.rgb, color.a * coverage);
+}
+`
+
+// NewGLRenderer creates a new renderer for the active OpenGL context
+func NewGLRenderer() *GLRenderer {
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	return &GLRenderer{
+		transforms: []Transform{IdentityTransform()},
+		textures:   map[*font.AtlasPage]*glTexture{},
+		images:     map[image.Image]uint32{},
+	}
+}
+
+// GetShaderError gets the error from compiling the shader that draws distance fields, or nil if it compiled
+// or hasn't been needed yet. Glyphs are drawn by filling their outlines when it couldn't be compiled.
+func (renderer *GLRenderer) GetShaderError() error {
+	return renderer.shaderErr
+}
+
+// FillRect fills the rectangle described by bounds with a solid color
+func (renderer *GLRenderer) FillRect(bounds Bounds, color [4]float32) {
+	gl.Color4fv(&color[0])
+	gl.Begin(gl.QUADS)
+	gl.Vertex2f(bounds.X, bounds.Y)
+	gl.Vertex2f(bounds.X, bounds.Y+bounds.Height)
+	gl.Vertex2f(bounds.X+bounds.Width, bounds.Y+bounds.Height)
+	gl.Vertex2f(bounds.X+bounds.Width, bounds.Y)
+	gl.End()
+}
+
+// DrawPath draws a line through each of the points in order
+func (renderer *GLRenderer) DrawPath(points []Point, color [4]float32) {
+	gl.Color4fv(&color[0])
+	gl.Begin(gl.LINE_STRIP)
+	for _, point := range points {
+		gl.Vertex2f(point.X, point.Y)
+	}
+	gl.End()
+}
+
+// DrawImage stretches an image to fill the rectangle described by bounds. Each image is uploaded to a
+// texture the first time it is drawn, so images shouldn't be changed once they have been drawn.
+func (renderer *GLRenderer) DrawImage(img image.Image, bounds Bounds) {
+	texture, ok := renderer.images[img]
+	if !ok {
+		rgba := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+		gl.GenTextures(1, &texture)
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Dx()), int32(rgba.Rect.Dy()), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
+		renderer.images[img] = texture
+	}
+	gl.Enable(gl.TEXTURE_2D)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.Color4f(1, 1, 1, 1)
+	gl.Begin(gl.QUADS)
+	gl.TexCoord2f(0, 0)
+	gl.Vertex2f(bounds.X, bounds.Y)
+	gl.TexCoord2f(0, 1)
+	gl.Vertex2f(bounds.X, bounds.Y+bounds.Height)
+	gl.TexCoord2f(1, 1)
+	gl.Vertex2f(bounds.X+bounds.Width, bounds.Y+bounds.Height)
+	gl.TexCoord2f(1, 0)
+	gl.Vertex2f(bounds.X+bounds.Width, bounds.Y)
+	gl.End()
+	gl.Disable(gl.TEXTURE_2D)
+}
+
+// PushTransform saves the current transform and then applies the given transform on top of it
+func (renderer *GLRenderer) PushTransform(transform Transform) {
+	renderer.transforms = append(renderer.transforms, renderer.currentTransform().Multiply(transform))
+	gl.PushMatrix()
+	m := transform.Matrix()
+	gl.MultMatrixf(&m[0])
+}
+
+// PopTransform restores the transform saved by the matching call to PushTransform
+func (renderer *GLRenderer) PopTransform() {
+	if len(renderer.transforms) > 1 {
+		renderer.transforms = renderer.transforms[:len(renderer.transforms)-1]
+	}
+	gl.PopMatrix()
+}
+
+// PushClip saves the current clip and then restricts drawing to the part of it inside the rectangle
+// described by bounds, using the scissor test. Rotated rectangles clip to the pixels around them. Clips
+// assume that the renderer's coordinates are the viewport's pixels with y pointing down.
+func (renderer *GLRenderer) PushClip(bounds Bounds) {
+	clip := transformedBounds(renderer.currentTransform(), float64(bounds.X), float64(bounds.Y), float64(bounds.Width), float64(bounds.Height))
+	if len(renderer.clips) > 0 {
+		clip = clip.Intersect(renderer.clips[len(renderer.clips)-1])
+	}
+	renderer.clips = append(renderer.clips, clip)
+	renderer.applyClip()
+}
+
+// PopClip restores the clip saved by the matching call to PushClip
+func (renderer *GLRenderer) PopClip() {
+	if len(renderer.clips) > 0 {
+		renderer.clips = renderer.clips[:len(renderer.clips)-1]
+	}
+	renderer.applyClip()
+}
+
+// applyClip sets the scissor box to the current clip, or turns the scissor test off when there isn't one
+func (renderer *GLRenderer) applyClip() {
+	if len(renderer.clips) == 0 {
+		gl.Disable(gl.SCISSOR_TEST)
+		return
+	}
+	clip := renderer.clips[len(renderer.clips)-1]
+	// the scissor box is measured from the bottom left corner of the window
+	var viewport [4]int32
+	gl.GetIntegerv(gl.VIEWPORT, &viewport[0])
+	gl.Enable(gl.SCISSOR_TEST)
+	gl.Scissor(viewport[0]+int32(clip.Min.X), viewport[1]+viewport[3]-int32(clip.Max.Y), int32(clip.Dx()), int32(clip.Dy()))
+}
+
+// DrawText draws a string with its baseline starting at the given position
+func (renderer *GLRenderer) DrawText(fnt *font.LoadedFont, str string, x float32, y float32, color [4]float32) {
+	glyphs, _ := font.PositionGlyphs(fnt, str)
+	renderer.DrawGlyphs(fnt, glyphs, x, y, color)
+}
+
+// DrawGlyphs draws glyphs that have already been positioned relative to the given position. Unscaled
+// text is copied from the font's glyph atlas, while scaled or rotated text is filled from the glyph
+// outlines so that it stays sharp. Text from distance field fonts is always drawn from the atlas.
+func (renderer *GLRenderer) DrawGlyphs(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32) {
+	renderer.DrawGlyphsWithEffects(fnt, glyphs, x, y, color, font.TextEffects{})
+}
+
+// DrawGlyphsWithEffects draws positioned glyphs like DrawGlyphs, along with their underlines,
+// strikethroughs, shadow and outline. Glows are only drawn around glyphs from distance field fonts.
+func (renderer *GLRenderer) DrawGlyphsWithEffects(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32, effects font.TextEffects) {
+	lines := effects.Lines(fnt, glyphs)
+	// each layer is drawn for every glyph before the next layer starts, so effects around one glyph never
+	// cover another
+	for _, layer := range effects.Layers(color) {
+		renderer.drawLayer(fnt, glyphs, x, y, layer)
+		if layer.Falloff > 0 {
+			continue
+		}
+		for _, line := range lines {
+			line = layer.Grow(line)
+			renderer.FillRect(NewBounds(x+float32(line.X), y+float32(line.Y), float32(line.Width), float32(line.Height)), layer.Color)
+		}
+	}
+}
+
+// drawLayer draws one layer of glyphs. Glyphs without distance fields are drawn at each of the layer's
+// stamps, so outlines with translucent colors are darker where the stamps overlap.
+func (renderer *GLRenderer) drawLayer(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, layer font.TextLayer) {
+	transform := renderer.currentTransform()
+	if fnt.IsDistanceField() {
+		if program := renderer.distanceFieldProgram(); program != nil {
+			renderer.drawDistanceField(program, font.DrawGlyphs(fnt, glyphs, float64(x)+layer.X, float64(y)+layer.Y), layer)
+			return
+		}
+	}
+	stamps := layer.Stamps()
+	if len(stamps) == 0 {
+		return
+	}
+
+	gl.Color4fv(&layer.Color[0])
+	if len(stamps) > 1 || !transform.IsTranslation() || fnt.IsDistanceField() {
+		gl.Begin(gl.TRIANGLES)
+		for _, stamp := range stamps {
+			for _, point := range font.GetTrianglesForGlyphs(fnt, glyphs, float64(x)+stamp.X, float64(y)+stamp.Y) {
+				gl.Vertex2d(point.X, point.Y)
+			}
+		}
+		gl.End()
+		return
+	}
+
+	// lay the string out in window coordinates so the glyphs land exactly on pixels
+	quads := font.DrawGlyphs(fnt, glyphs, float64(x+transform[2])+stamps[0].X, float64(y+transform[5])+stamps[0].Y)
+	gl.Enable(gl.TEXTURE_2D)
+	renderer.drawQuads(quads, -transform[2], -transform[5], nil)
+	gl.Disable(gl.TEXTURE_2D)
+}
+
+// drawDistanceField draws one layer of quads from distance field atlas pages
+func (renderer *GLRenderer) drawDistanceField(program *distanceFieldProgram, quads []font.GlyphQuad, layer font.TextLayer) {
+	gl.UseProgram(program.id)
+	gl.Enable(gl.TEXTURE_2D)
+	gl.Uniform4fv(program.color, 1, &layer.Color[0])
+	gl.Uniform1f(program.offset, float32(layer.Offset))
+	gl.Uniform1f(program.falloff, float32(layer.Falloff))
+	if layer.Offset != 0 || layer.Falloff != 0 {
+		// glyphs from fallback fonts without distance fields can only be filled
+		var fields []font.GlyphQuad
+		for _, quad := range quads {
+			if quad.Spread > 0 {
+				fields = append(fields, quad)
+			}
+		}
+		quads = fields
+	}
+	renderer.drawQuads(quads, 0, 0, program)
+	gl.Disable(gl.TEXTURE_2D)
+	gl.UseProgram(0)
+}
+
+// drawQuads draws each quad as a textured rectangle offset by dx, dy, binding each atlas page in turn.
+// When drawing with the distance field program, its spread is updated for each page.
+func (renderer *GLRenderer) drawQuads(quads []font.GlyphQuad, dx float32, dy float32, program *distanceFieldProgram) {
+	for start := 0; start < len(quads); {
+		page := quads[start].Page
+		renderer.bindPage(page, quads[start].Spread > 0)
+		if program != nil {
+			gl.Uniform1f(program.spread, float32(quads[start].Spread))
+		}
+		size := float32(page.Image.Rect.Dx())
+		gl.Begin(gl.QUADS)
+		for _, quad := range quads[start:] {
+			if quad.Page != page {
+				break
+			}
+			x0 := float32(quad.X) + dx
+			y0 := float32(quad.Y) + dy
+			x1 := x0 + float32(quad.Source.Dx())
+			y1 := y0 + float32(quad.Source.Dy())
+			s0 := float32(quad.Source.Min.X) / size
+			t0 := float32(quad.Source.Min.Y) / size
+			s1 := float32(quad.Source.Max.X) / size
+			t1 := float32(quad.Source.Max.Y) / size
+			gl.TexCoord2f(s0, t0)
+			gl.Vertex2f(x0, y0)
+			gl.TexCoord2f(s0, t1)
+			gl.Vertex2f(x0, y1)
+			gl.TexCoord2f(s1, t1)
+			gl.Vertex2f(x1, y1)
+			gl.TexCoord2f(s1, t0)
+			gl.Vertex2f(x1, y0)
+			start++
+		}
+		gl.End()
+	}
+}
+
+// distanceFieldProgram gets the shader program for drawing distance fields, compiling it the first time it
+// is needed. It returns nil if the program can't be compiled, in which case the glyph outlines are filled instead.
+func (renderer *GLRenderer) distanceFieldProgram() *distanceFieldProgram {
+	if renderer.distanceField != nil {
+		if renderer.distanceField.id == 0 {
+			return nil
+		}
+		return renderer.distanceField
+	}
+	renderer.distanceField = &distanceFieldProgram{}
+	id, err := compileFragmentProgram(distanceFieldShader)
+	if err != nil {
+		renderer.shaderErr = err
+		return nil
+	}
+	renderer.distanceField = &distanceFieldProgram{
+		id:      id,
+		color:   gl.GetUniformLocation(id, gl.Str("color\x00")),
+		spread:  gl.GetUniformLocation(id, gl.Str("spread\x00")),
+		offset:  gl.GetUniformLocation(id, gl.Str("offset\x00")),
+		falloff: gl.GetUniformLocation(id, gl.Str("falloff\x00")),
+	}
+	return renderer.distanceField
+}
+
+// compileFragmentProgram compiles a fragment shader into a program that uses the fixed function pipeline
+// for everything else
+func compileFragmentProgram(source string) (uint32, error) {
+	shader := gl.CreateShader(gl.FRAGMENT_SHADER)
+	sources, free := gl.Strs(source + "\x00")
+	gl.ShaderSource(shader, 1, sources, nil)
+	free()
+	gl.CompileShader(shader)
+	defer gl.DeleteShader(shader)
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var length int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
+		log := strings.Repeat("\x00", int(length+1))
+		gl.GetShaderInfoLog(shader, length, nil, gl.Str(log))
+		return 0, fmt.Errorf("Could not compile shader: %s", strings.TrimRight(log, "\x00"))
+	}
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, shader)
+	gl.LinkProgram(program)
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var length int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &length)
+		log := strings.Repeat("\x00", int(length+1))
+		gl.GetProgramInfoLog(program, length, nil, gl.Str(log))
+		gl.DeleteProgram(program)
+		return 0, fmt.Errorf("Could not link shader: %s", strings.TrimRight(log, "\x00"))
+	}
+	return program, nil
+}
+
+// bindPage binds the texture for an atlas page, uploading the page if it has changed since it was last drawn.
+// Distance fields are filtered smoothly, since they are almost never drawn at their original size.
+func (renderer *GLRenderer) bindPage(page *font.AtlasPage, distanceField bool) {
+	texture, ok := renderer.textures[page]
+	if !ok {
+		texture = &glTexture{version: -1}
+		filter := int32(gl.NEAREST)
+		if distanceField {
+			filter = gl.LINEAR
+		}
+		gl.GenTextures(1, &texture.id)
+		gl.BindTexture(gl.TEXTURE_2D, texture.id)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, filter)
+		gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, filter)
+		renderer.textures[page] = texture
+	}
+	gl.BindTexture(gl.TEXTURE_2D, texture.id)
+	if texture.version != page.Version {
+		gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+		gl.TexImage2D(gl.TEXTURE_2D, 0, gl.ALPHA, int32(page.Image.Rect.Dx()), int32(page.Image.Rect.Dy()), 0, gl.ALPHA, gl.UNSIGNED_BYTE, gl.Ptr(page.Image.Pix))
+		texture.version = page.Version
+	}
+}
+
+// currentTransform gets the transform on the top of the stack
+func (renderer *GLRenderer) currentTransform() Transform {
+	return renderer.transforms[len(renderer.transforms)-1]
+}
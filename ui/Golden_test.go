@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"./font"
+)
+
+// run "go test ./ui -update" to regenerate the golden images after an intentional change to how things look
+var update = flag.Bool("update", false, "regenerate the golden images in testdata")
+
+// GoldenBackground is the color behind every component rendered for a golden image test
+var GoldenBackground = [4]float32{0, 0, 0, 1}
+
+// CheckGolden renders a component offscreen and compares it against testdata/<name>.png, allowing each
+// color channel to differ by up to tolerance. When the images differ, the rendered image and an image
+// highlighting the differences are written next to the golden image.
+func CheckGolden(t *testing.T, name string, component Component, width int, height int, tolerance uint8) {
+	t.Helper()
+	actual := RenderToImage(component, width, height, GoldenBackground)
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		if err := writePNG(path, actual); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := readPNG(path)
+	if err != nil {
+		t.Fatalf("Unable to read golden image (run with -update to create it): %s", err)
+	}
+	if expected.Bounds() != actual.Bounds() {
+		t.Fatalf("Invalid image size: expected %v, got %v", expected.Bounds(), actual.Bounds())
+	}
+	diff := image.NewRGBA(actual.Bounds())
+	mismatched := 0
+	for y := actual.Bounds().Min.Y; y < actual.Bounds().Max.Y; y++ {
+		for x := actual.Bounds().Min.X; x < actual.Bounds().Max.X; x++ {
+			e := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+			a := actual.RGBAAt(x, y)
+			if channelDiff(e.R, a.R) > tolerance || channelDiff(e.G, a.G) > tolerance || channelDiff(e.B, a.B) > tolerance || channelDiff(e.A, a.A) > tolerance {
+				mismatched++
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				gray := uint8((uint16(e.R) + uint16(e.G) + uint16(e.B)) / 12)
+				diff.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+			}
+		}
+	}
+	if mismatched > 0 {
+		actualPath := filepath.Join("testdata", name+".actual.png")
+		diffPath := filepath.Join("testdata", name+".diff.png")
+		if err := writePNG(actualPath, actual); err != nil {
+			t.Error(err)
+		}
+		if err := writePNG(diffPath, diff); err != nil {
+			t.Error(err)
+		}
+		t.Errorf("%d pixels differ from %s, see %s and %s", mismatched, path, actualPath, diffPath)
+	}
+}
+
+// channelDiff determines how far apart two color channels are
+func channelDiff(a uint8, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+func TestGoldenLayout(t *testing.T) {
+	layout := NewTableLayout()
+	CreateRenderableBox(&layout, 20, 10, 0, 0, 1, 1, [4]float32{1, 1, 1, 1})
+	CreateRenderableBox(&layout, 15, 10, 0, 1, 1, 1, [4]float32{1, 1, 0, 1})
+	CreateRenderableBox(&layout, 10, 10, 0, 2, 1, 1, [4]float32{1, 0, 1, 1})
+	CreateRenderableBox(&layout, 60, 10, 1, 0, 1, 2, [4]float32{1, 0, 0, 1})
+	CreateRenderableBox(&layout, 20, 10, 1, 2, 1, 1, [4]float32{0, 1, 1, 1})
+	CreateRenderableBox(&layout, 10, 10, 2, 0, 1, 1, [4]float32{0, 1, 0, 1})
+	CreateRenderableBox(&layout, 80, 10, 2, 1, 1, 2, [4]float32{0, 0, 1, 1})
+	CheckGolden(t, "layout", &layout, 120, 40, 0)
+}
+
+type textComponent struct {
+	Bounds  Bounds
+	Font    *font.LoadedFont
+	Text    string
+	Scale   float32
+	Effects font.TextEffects
+}
+
+func (text textComponent) GetBounds() Bounds {
+	return text.Bounds
+}
+
+func (text *textComponent) SetBounds(bounds Bounds) {
+	text.Bounds = bounds
+}
+
+func (text textComponent) GetMinimumSize() Bounds {
+	return NewBounds(0, 0, 0, 0)
+}
+
+func (text textComponent) Render(renderer Renderer) {
+	x, y := float32(4), text.Bounds.Height-8
+	if text.Scale != 0 {
+		renderer.PushTransform(ScaleTransform(text.Scale, text.Scale))
+		defer renderer.PopTransform()
+		x, y = x/text.Scale, y/text.Scale
+	}
+	glyphs, _ := font.PositionGlyphs(text.Font, text.Text)
+	renderer.DrawGlyphsWithEffects(text.Font, glyphs, x, y, [4]float32{1, 1, 1, 1}, text.Effects)
+}
+
+func TestGoldenText(t *testing.T) {
+	fnt := LoadTestFont(t, 24)
+	CheckGolden(t, "text", &textComponent{Font: fnt, Text: "Mars 80"}, 100, 32, 8)
+}
+
+func TestGoldenScaledText(t *testing.T) {
+	fnt := LoadTestFont(t, 12)
+	CheckGolden(t, "text_scaled", &textComponent{Font: fnt, Text: "Mars 80", Scale: 2}, 100, 32, 8)
+}
+
+func TestGoldenDistanceField(t *testing.T) {
+	regular := LoadTestFont(t, 12)
+	fnt := font.NewDistanceFieldFont(regular.Font, 12, 4)
+	CheckGolden(t, "distance_field", &textComponent{Font: fnt, Text: "Mars 80", Scale: 2}, 100, 32, 8)
+	CheckGolden(t, "distance_field_effects", &textComponent{
+		Font:  fnt,
+		Text:  "Mars 80",
+		Scale: 2,
+		Effects: font.TextEffects{
+			OutlineWidth: 1,
+			OutlineColor: [4]float32{1, 0, 0, 1},
+			GlowRadius:   3,
+			GlowColor:    [4]float32{0, 0.5, 1, 0.5},
+		},
+	}, 100, 32, 8)
+}
+
+func TestGoldenTextEffects(t *testing.T) {
+	fnt := LoadTestFont(t, 24)
+	effects := font.TextEffects{
+		Underline:     true,
+		Strikethrough: true,
+		OutlineWidth:  1.5,
+		OutlineColor:  [4]float32{1, 0, 0, 1},
+		ShadowOffset:  font.Point{X: 2, Y: 2},
+		ShadowColor:   [4]float32{0, 0.5, 1, 1},
+	}
+	CheckGolden(t, "text_effects", &textComponent{Font: fnt, Text: "Mars 80", Effects: effects}, 100, 40, 8)
+	CheckGolden(t, "text_effects_scaled", &textComponent{Font: fnt, Text: "Mars 80", Scale: 0.5, Effects: effects}, 100, 40, 8)
+
+	distanceField := font.NewDistanceFieldFont(fnt.Font, 12, 4)
+	effects.OutlineWidth = 1
+	CheckGolden(t, "distance_field_decorations", &textComponent{Font: distanceField, Text: "Mars 80", Scale: 2, Effects: effects}, 100, 40, 8)
+}
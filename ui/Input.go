@@ -0,0 +1,49 @@
+package ui
+
+// Key is a key on the keyboard that components respond to. Keys are kept separate from the window
+// library's keys so that components can be driven and tested without a window.
+type Key int
+
+const (
+	// KeyUnknown is any key that components don't respond to
+	KeyUnknown Key = iota
+	KeyLeft
+	KeyRight
+	KeyUp
+	KeyDown
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyBackspace
+	KeyDelete
+	KeyEnter
+	KeyTab
+	KeyA
+	KeyC
+	KeyV
+	KeyX
+)
+
+// Modifiers are the modifier keys held down during a key press or mouse click
+type Modifiers int
+
+const (
+	ModShift Modifiers = 1 << iota
+	ModControl
+	ModAlt
+	ModSuper
+)
+
+// IsShortcut determines whether the modifiers turn a key press into a shortcut like copy or paste, which
+// is control on most systems and command on macOS
+func (mods Modifiers) IsShortcut() bool {
+	return mods&(ModControl|ModSuper) != 0
+}
+
+// Clipboard holds text that has been copied so it can be pasted, like the system clipboard through a
+// glfw.Window
+type Clipboard interface {
+	GetClipboardString() (string, error)
+	SetClipboardString(str string)
+}
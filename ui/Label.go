@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"math"
+
+	"./font"
+)
+
+// HorizontalAlignment describes where content sits horizontally within its bounds
+type HorizontalAlignment int
+
+const (
+	// AlignLeft places content against the left edge of its bounds
+	AlignLeft HorizontalAlignment = 0
+	// AlignCenter places content halfway between the left and right edges of its bounds
+	AlignCenter HorizontalAlignment = 1
+	// AlignRight places content against the right edge of its bounds
+	AlignRight HorizontalAlignment = 2
+)
+
+// VerticalAlignment describes where content sits vertically within its bounds
+type VerticalAlignment int
+
+const (
+	// AlignTop places content against the top edge of its bounds
+	AlignTop VerticalAlignment = 0
+	// AlignMiddle places content halfway between the top and bottom edges of its bounds
+	AlignMiddle VerticalAlignment = 1
+	// AlignBottom places content against the bottom edge of its bounds
+	AlignBottom VerticalAlignment = 2
+)
+
+// Label is a component that draws a single line of text
+type Label struct {
+	Bounds Bounds
+	Text   string
+	Font   *font.LoadedFont
+	Color  [4]float32
+	// Effects decorate the text with lines, a shadow and an outline
+	Effects             font.TextEffects
+	HorizontalAlignment HorizontalAlignment
+	VerticalAlignment   VerticalAlignment
+}
+
+// NewLabel creates a new label that draws text in the top left corner of its bounds
+func NewLabel(text string, fnt *font.LoadedFont, color [4]float32) Label {
+	return Label{
+		Text:  text,
+		Font:  fnt,
+		Color: color,
+	}
+}
+
+// CreateLabel creates a new label and adds it to the layout manager
+func CreateLabel(layout *TableLayout, text string, fnt *font.LoadedFont, color [4]float32, row int, col int, rowSpan int, colSpan int) *Label {
+	label := NewLabel(text, fnt, color)
+	layout.Add(&label, row, col, rowSpan, colSpan)
+	return &label
+}
+
+// GetBounds determines the bounds of the component
+func (label Label) GetBounds() Bounds {
+	return label.Bounds
+}
+
+// SetBounds sets the bounds of the component
+func (label *Label) SetBounds(bounds Bounds) {
+	label.Bounds = bounds
+}
+
+// GetMinimumSize determines the minimum size of the component, which is just big enough to fit the text
+func (label Label) GetMinimumSize() Bounds {
+	return NewBounds(0, 0, float32(math.Ceil(label.Font.GetAdvance(label.Text))), float32(math.Ceil(label.Font.GetLineHeight())))
+}
+
+// Render draws the text aligned within the bounds of the label
+func (label Label) Render(renderer Renderer) {
+	advance := float32(label.Font.GetAdvance(label.Text))
+	ascent := float32(label.Font.GetAscent())
+	lineHeight := float32(label.Font.GetLineHeight())
+	var x, y float32
+	switch label.HorizontalAlignment {
+	case AlignLeft:
+		x = 0
+	case AlignCenter:
+		x = (label.Bounds.Width - advance) / 2
+	case AlignRight:
+		x = label.Bounds.Width - advance
+	}
+	switch label.VerticalAlignment {
+	case AlignTop:
+		y = ascent
+	case AlignMiddle:
+		y = (label.Bounds.Height-lineHeight)/2 + ascent
+	case AlignBottom:
+		y = label.Bounds.Height - lineHeight + ascent
+	}
+	glyphs, _ := font.PositionGlyphs(label.Font, label.Text)
+	renderer.DrawGlyphsWithEffects(label.Font, glyphs, x, y, label.Color, label.Effects)
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"math"
+	"testing"
+
+	"./font"
+)
+
+func LoadTestFont(t *testing.T, size float64) *font.LoadedFont {
+	fnt, err := font.LoadDefaultFont(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fnt
+}
+
+func LoadTestFontManager(t *testing.T) *font.FontManager {
+	manager := font.NewFontManager()
+	if err := manager.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func TestLabelLayout(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	layout := NewTableLayout()
+	a := CreateLabel(&layout, "Name", fnt, [4]float32{1, 1, 1, 1}, 0, 0, 1, 1)
+	b := CreateLabel(&layout, "Decimator", fnt, [4]float32{1, 1, 1, 1}, 0, 1, 1, 1)
+	layout.Layout()
+	width := float32(math.Ceil(fnt.GetAdvance("Name")))
+	height := float32(math.Ceil(fnt.GetLineHeight()))
+	if a.GetBounds() != NewBounds(0, 0, width, height) {
+		t.Errorf("Invalid bounds for the first label: %v", a.GetBounds())
+	}
+	if b.GetBounds().X != width || b.GetBounds().Width != float32(math.Ceil(fnt.GetAdvance("Decimator"))) {
+		t.Errorf("Invalid bounds for the second label: %v", b.GetBounds())
+	}
+}
+
+func TestGoldenLabel(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	for _, test := range []struct {
+		name       string
+		horizontal HorizontalAlignment
+		vertical   VerticalAlignment
+	}{
+		{"label_top_left", AlignLeft, AlignTop},
+		{"label_middle_center", AlignCenter, AlignMiddle},
+		{"label_bottom_right", AlignRight, AlignBottom},
+	} {
+		label := NewLabel("Label", fnt, [4]float32{1, 0.5, 0, 1})
+		label.HorizontalAlignment = test.horizontal
+		label.VerticalAlignment = test.vertical
+		CheckGolden(t, test.name, &label, 80, 40, 8)
+	}
+}
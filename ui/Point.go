@@ -0,0 +1,22 @@
+package ui
+
+import "fmt"
+
+// Point represents a position on the screen
+type Point struct {
+	X float32
+	Y float32
+}
+
+// NewPoint creates a new Point object with the specified values
+func NewPoint(x float32, y float32) Point {
+	return Point{
+		X: x,
+		Y: y,
+	}
+}
+
+// String converts the point to a string
+func (point Point) String() string {
+	return fmt.Sprintf("(%f, %f)", point.X, point.Y)
+}
@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"image"
+
+	"./font"
+)
+
+// Renderer is a drawing backend that components use to render themselves
+type Renderer interface {
+	// FillRect fills the rectangle described by bounds with a solid color
+	FillRect(bounds Bounds, color [4]float32)
+	// DrawPath draws a line through each of the points in order
+	DrawPath(points []Point, color [4]float32)
+	// DrawImage stretches an image to fill the rectangle described by bounds
+	DrawImage(img image.Image, bounds Bounds)
+	// PushTransform saves the current transform and then applies the given transform on top of it
+	PushTransform(transform Transform)
+	// PopTransform restores the transform saved by the matching call to PushTransform
+	PopTransform()
+	// PushClip saves the current clip and then restricts drawing to the part of it inside the rectangle
+	// described by bounds
+	PushClip(bounds Bounds)
+	// PopClip restores the clip saved by the matching call to PushClip
+	PopClip()
+	// DrawText draws a string with its baseline starting at the given position
+	DrawText(fnt *font.LoadedFont, str string, x float32, y float32, color [4]float32)
+	// DrawGlyphs draws glyphs that have already been positioned, like the lines of a font.TextLayout,
+	// relative to the given position
+	DrawGlyphs(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32)
+	// DrawGlyphsWithEffects draws positioned glyphs like DrawGlyphs, decorated with lines and surrounded by
+	// a shadow, an outline and a glow. Glows are only drawn around glyphs from distance field fonts.
+	DrawGlyphsWithEffects(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32, effects font.TextEffects)
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"image"
+	"math"
+
+	"./font"
+)
+
+// RichLabel is a component that draws text written in markup, with inline colors, font faces, sizes,
+// underlines, strikethroughs and images. See font.ParseMarkup for the tags it understands.
+type RichLabel struct {
+	Bounds Bounds
+	Fonts  *font.FontManager
+	// Style is how text outside of any markup tags looks
+	Style font.TextStyle
+	// Images are the images that markup can draw inline, by name. Change them with SetImages.
+	Images map[string]image.Image
+	// MaxWidth is the width lines are wrapped to fit in, or zero to only break lines at newlines
+	MaxWidth            float64
+	HorizontalAlignment HorizontalAlignment
+	VerticalAlignment   VerticalAlignment
+	runs                []font.TextRun
+	// cache is the last layout of the text, shared between copies of the label so that drawing doesn't lay
+	// the text out again every frame
+	cache *richLabelCache
+}
+
+// richLabelCache is a layout of a rich label's text, along with what it was laid out with
+type richLabelCache struct {
+	generation int
+	options    font.LayoutOptions
+	layout     *font.RichTextLayout
+}
+
+// NewRichLabel creates a new label that draws markup in the top left corner of its bounds, starting in the
+// given style. It fails if the markup is invalid or uses a font or image that isn't available.
+func NewRichLabel(markup string, fonts *font.FontManager, style font.TextStyle, images map[string]image.Image) (RichLabel, error) {
+	label := RichLabel{
+		Fonts:  fonts,
+		Style:  style,
+		Images: images,
+	}
+	err := label.SetMarkup(markup)
+	return label, err
+}
+
+// CreateRichLabel creates a new rich label and adds it to the layout manager
+func CreateRichLabel(layout *TableLayout, markup string, fonts *font.FontManager, style font.TextStyle, images map[string]image.Image, row int, col int, rowSpan int, colSpan int) (*RichLabel, error) {
+	label, err := NewRichLabel(markup, fonts, style, images)
+	if err != nil {
+		return nil, err
+	}
+	layout.Add(&label, row, col, rowSpan, colSpan)
+	return &label, nil
+}
+
+// SetMarkup changes the text of the label. The old text is kept if the markup is invalid or uses a font
+// or image that isn't available.
+func (label *RichLabel) SetMarkup(markup string) error {
+	runs, err := font.ParseMarkup(markup, label.Style)
+	if err != nil {
+		return err
+	}
+	if _, err := font.LayoutRichText(label.Fonts, runs, label.Images, label.layoutOptions()); err != nil {
+		return err
+	}
+	label.runs = runs
+	label.cache = &richLabelCache{}
+	return nil
+}
+
+// SetImages changes the images that markup can draw inline
+func (label *RichLabel) SetImages(images map[string]image.Image) {
+	label.Images = images
+	label.cache = &richLabelCache{}
+}
+
+// GetBounds determines the bounds of the component
+func (label RichLabel) GetBounds() Bounds {
+	return label.Bounds
+}
+
+// SetBounds sets the bounds of the component
+func (label *RichLabel) SetBounds(bounds Bounds) {
+	label.Bounds = bounds
+}
+
+// GetMinimumSize determines the minimum size of the component, which is just big enough to fit the text
+func (label RichLabel) GetMinimumSize() Bounds {
+	layout := label.layout()
+	return NewBounds(0, 0, float32(math.Ceil(label.textWidth(layout))), float32(math.Ceil(layout.Height)))
+}
+
+// Render draws the text aligned within the bounds of the label
+func (label RichLabel) Render(renderer Renderer) {
+	layout := label.layout()
+	width := float32(label.textWidth(layout))
+	var x, y float32
+	switch label.HorizontalAlignment {
+	case AlignLeft:
+		x = 0
+	case AlignCenter:
+		x = (label.Bounds.Width - width) / 2
+	case AlignRight:
+		x = label.Bounds.Width - width
+	}
+	switch label.VerticalAlignment {
+	case AlignTop:
+		y = 0
+	case AlignMiddle:
+		y = (label.Bounds.Height - float32(layout.Height)) / 2
+	case AlignBottom:
+		y = label.Bounds.Height - float32(layout.Height)
+	}
+	for _, line := range layout.Lines {
+		for _, piece := range line.Pieces {
+			if piece.Image != nil {
+				renderer.DrawImage(piece.Image, NewBounds(x+float32(piece.Bounds.X), y+float32(piece.Bounds.Y), float32(piece.Bounds.Width), float32(piece.Bounds.Height)))
+				continue
+			}
+			effects := font.TextEffects{Underline: piece.Style.Underline, Strikethrough: piece.Style.Strikethrough}
+			renderer.DrawGlyphsWithEffects(piece.Font, piece.Glyphs, x, y, piece.Style.Color, effects)
+		}
+	}
+}
+
+// layout gets the layout of the label's text, laying it out again if the fonts or the options have changed
+// since it was last laid out
+func (label RichLabel) layout() *font.RichTextLayout {
+	options := label.layoutOptions()
+	generation := label.Fonts.GetGeneration()
+	if label.cache != nil && label.cache.layout != nil && label.cache.generation == generation && label.cache.options == options {
+		return label.cache.layout
+	}
+	layout, err := font.LayoutRichText(label.Fonts, label.runs, label.Images, options)
+	if err != nil {
+		layout = &font.RichTextLayout{}
+	}
+	if label.cache != nil {
+		*label.cache = richLabelCache{generation: generation, options: options, layout: layout}
+	}
+	return layout
+}
+
+// textWidth gets the width of the block the lines of text are aligned within. Lines that are wrapped are
+// aligned within the maximum width rather than the widest line, so centered and right aligned text reaches
+// out to it.
+func (label RichLabel) textWidth(layout *font.RichTextLayout) float64 {
+	if label.MaxWidth > 0 && label.HorizontalAlignment != AlignLeft {
+		return label.MaxWidth
+	}
+	return layout.Width
+}
+
+// layoutOptions gets the options the label's text is laid out with, lining up the lines of text with each
+// other the same way the text is aligned within the label
+func (label RichLabel) layoutOptions() font.LayoutOptions {
+	options := font.LayoutOptions{MaxWidth: label.MaxWidth}
+	switch label.HorizontalAlignment {
+	case AlignCenter:
+		options.Alignment = font.AlignCenter
+	case AlignRight:
+		options.Alignment = font.AlignRight
+	}
+	return options
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"./font"
+)
+
+func TestRichLabelLayout(t *testing.T) {
+	manager := LoadTestFontManager(t)
+	style := font.TextStyle{Family: "Go", Size: 16, Color: [4]float32{1, 1, 1, 1}}
+	layout := NewTableLayout()
+	a, err := CreateRichLabel(&layout, "[b]Name[/b]", manager, style, nil, 0, 0, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CreateRichLabel(&layout, "Small\n[size=24]Large", manager, style, nil, 0, 1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout.Layout()
+	bold, _ := manager.GetFont("Go", font.Bold, 16)
+	regular, _ := manager.GetFont("Go", font.Regular, 16)
+	large, _ := manager.GetFont("Go", font.Regular, 24)
+	width := float32(math.Ceil(bold.GetAdvance("Name")))
+	if a.GetBounds().X != 0 || a.GetBounds().Width != width {
+		t.Errorf("Invalid bounds for the first label: %v", a.GetBounds())
+	}
+	height := float32(math.Ceil(regular.GetLineHeight() + large.GetLineHeight()))
+	if b.GetBounds().X != width || b.GetBounds().Width != float32(math.Ceil(large.GetAdvance("Large"))) || b.GetBounds().Height != height {
+		t.Errorf("Invalid bounds for the second label: %v", b.GetBounds())
+	}
+
+	if err := b.SetMarkup("[b]unclosed[/i]"); err == nil {
+		t.Error("Expected an error for invalid markup")
+	}
+	if b.GetMinimumSize().Height != height {
+		t.Error("Expected invalid markup to keep the old text")
+	}
+	if _, err := NewRichLabel("[img=missing]", manager, style, nil); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
+
+func TestRichLabelWrappedAlignment(t *testing.T) {
+	manager := LoadTestFontManager(t)
+	style := font.TextStyle{Family: "Go", Size: 16, Color: [4]float32{1, 1, 1, 1}}
+	fnt, _ := manager.GetFont("Go", font.Regular, 16)
+	advance := fnt.GetAdvance("Hi")
+	for _, test := range []struct {
+		alignment HorizontalAlignment
+		left      float64
+	}{
+		{AlignCenter, (200 - advance) / 2},
+		{AlignRight, 200 - advance},
+	} {
+		label, err := NewRichLabel("Hi", manager, style, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		label.MaxWidth = 200
+		label.HorizontalAlignment = test.alignment
+		label.SetBounds(NewBounds(0, 0, 200, 24))
+		if size := label.GetMinimumSize(); size.Width != 200 {
+			t.Errorf("Expected the label to ask for the width its lines are aligned in, got %v", size)
+		}
+		img := RenderToImage(&label, 200, 24, GoldenBackground)
+		left := -1
+		for x := 0; x < 200 && left < 0; x++ {
+			for y := 0; y < 24; y++ {
+				if img.RGBAAt(x, y).R > 128 {
+					left = x
+					break
+				}
+			}
+		}
+		if math.Abs(float64(left)-test.left) > 3 {
+			t.Errorf("Expected text aligned %d to start near %f, got %d", test.alignment, test.left, left)
+		}
+	}
+}
+
+func TestRichLabelLayoutCache(t *testing.T) {
+	manager := LoadTestFontManager(t)
+	label, err := NewRichLabel("Olympus [b]Mons", manager, font.TextStyle{Family: "Go", Size: 16}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := label.layout()
+	if label.layout() != first {
+		t.Error("Expected the layout to be reused while nothing has changed")
+	}
+	manager.SetDPI(font.DefaultDPI * 2)
+	second := label.layout()
+	if second == first || second.Width <= first.Width {
+		t.Error("Expected the text to be laid out again at the new DPI")
+	}
+	label.MaxWidth = 40
+	third := label.layout()
+	if third == second {
+		t.Error("Expected the text to be laid out again for a new maximum width")
+	}
+	if err := manager.RegisterBytes("Go", font.Bold, font.DefaultRegularFont); err != nil {
+		t.Fatal(err)
+	}
+	fourth := label.layout()
+	if fourth == third || fourth.Lines[len(fourth.Lines)-1].Pieces[0].Font.Font != manager.Families["Go"][font.Bold] {
+		t.Error("Expected the text to be laid out again with the new face")
+	}
+	manager.SetFallbacks("Go")
+	fifth := label.layout()
+	if fifth == fourth {
+		t.Error("Expected the text to be laid out again with the new fallbacks")
+	}
+	label.SetImages(map[string]image.Image{})
+	if label.layout() == fifth {
+		t.Error("Expected the text to be laid out again with the new images")
+	}
+}
+
+func TestGoldenRichLabel(t *testing.T) {
+	manager := LoadTestFontManager(t)
+	icon := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			icon.SetRGBA(x, y, color.RGBA{255, uint8(160 + 20*y), 0, 255})
+		}
+	}
+	style := font.TextStyle{Family: "Go", Size: 14, Color: [4]float32{0.8, 0.8, 0.8, 1}}
+	label, err := NewRichLabel("[color=#40a0ff]Ares[/color] sent [b]120[/b] [img=ore]\n[u]Outpost[/u] [size=20][s]lost", manager, style, map[string]image.Image{"ore": icon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	label.HorizontalAlignment = AlignCenter
+	label.VerticalAlignment = AlignMiddle
+	CheckGolden(t, "rich_label", &label, 160, 56, 8)
+}
@@ -0,0 +1,326 @@
+package ui
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"./font"
+
+	"github.com/golang/freetype/raster"
+	"golang.org/x/image/math/fixed"
+)
+
+// SoftwareRenderer is a renderer that rasterizes components into an in-memory image without needing a GPU
+type SoftwareRenderer struct {
+	Image      *image.RGBA
+	transforms []Transform
+	// clips are the areas of the image drawing is restricted to, with the current clip last
+	clips      []image.Rectangle
+	rasterizer *raster.Rasterizer
+	painter    *raster.RGBAPainter
+}
+
+// NewSoftwareRenderer creates a new renderer that draws into a transparent image of the given size
+func NewSoftwareRenderer(width int, height int) *SoftwareRenderer {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rasterizer := raster.NewRasterizer(width, height)
+	rasterizer.UseNonZeroWinding = true
+	return &SoftwareRenderer{
+		Image:      img,
+		transforms: []Transform{IdentityTransform()},
+		clips:      []image.Rectangle{img.Bounds()},
+		rasterizer: rasterizer,
+		painter:    raster.NewRGBAPainter(img),
+	}
+}
+
+// RenderToImage lays out a component to fill an image of the given size and renders it
+func RenderToImage(component Component, width int, height int, background [4]float32) *image.RGBA {
+	renderer := NewSoftwareRenderer(width, height)
+	renderer.Clear(background)
+	component.SetBounds(NewBounds(0, 0, float32(width), float32(height)))
+	component.Render(renderer)
+	return renderer.Image
+}
+
+// Clear replaces every pixel of the image with a solid color
+func (renderer *SoftwareRenderer) Clear(color [4]float32) {
+	draw.Draw(renderer.Image, renderer.Image.Bounds(), image.NewUniform(toNRGBA(color)), image.Point{}, draw.Src)
+}
+
+// FillRect fills the rectangle described by bounds with a solid color
+func (renderer *SoftwareRenderer) FillRect(bounds Bounds, color [4]float32) {
+	renderer.rasterizer.Start(renderer.toFixed(NewPoint(bounds.X, bounds.Y)))
+	renderer.rasterizer.Add1(renderer.toFixed(NewPoint(bounds.X, bounds.Y+bounds.Height)))
+	renderer.rasterizer.Add1(renderer.toFixed(NewPoint(bounds.X+bounds.Width, bounds.Y+bounds.Height)))
+	renderer.rasterizer.Add1(renderer.toFixed(NewPoint(bounds.X+bounds.Width, bounds.Y)))
+	renderer.rasterizer.Add1(renderer.toFixed(NewPoint(bounds.X, bounds.Y)))
+	renderer.paint(color)
+}
+
+// DrawPath draws a line through each of the points in order
+func (renderer *SoftwareRenderer) DrawPath(points []Point, color [4]float32) {
+	if len(points) < 2 {
+		return
+	}
+	path := raster.Path{}
+	path.Start(renderer.toFixed(points[0]))
+	for _, point := range points[1:] {
+		path.Add1(renderer.toFixed(point))
+	}
+	// lines are always a single pixel wide no matter how they are transformed, just like GL_LINE_STRIP
+	renderer.rasterizer.AddStroke(path, fixed.I(1), nil, nil)
+	renderer.paint(color)
+}
+
+// DrawImage stretches an image to fill the rectangle described by bounds, using the closest pixel of the
+// image for each pixel it covers
+func (renderer *SoftwareRenderer) DrawImage(img image.Image, bounds Bounds) {
+	transform := renderer.currentTransform()
+	inverse, ok := transform.Invert()
+	size := img.Bounds().Size()
+	if !ok || size.X == 0 || size.Y == 0 || bounds.Width <= 0 || bounds.Height <= 0 {
+		return
+	}
+	area := transformedBounds(transform, float64(bounds.X), float64(bounds.Y), float64(bounds.Width), float64(bounds.Height)).Intersect(renderer.currentClip())
+	for py := area.Min.Y; py < area.Max.Y; py++ {
+		for px := area.Min.X; px < area.Max.X; px++ {
+			point := inverse.Apply(NewPoint(float32(px)+0.5, float32(py)+0.5))
+			u, v := (point.X-bounds.X)/bounds.Width, (point.Y-bounds.Y)/bounds.Height
+			if u < 0 || v < 0 || u >= 1 || v >= 1 {
+				continue
+			}
+			x := img.Bounds().Min.X + int(u*float32(size.X))
+			y := img.Bounds().Min.Y + int(v*float32(size.Y))
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			renderer.blend(px, py, [4]float32{float32(c.R) / 255, float32(c.G) / 255, float32(c.B) / 255, float32(c.A) / 255}, 1)
+		}
+	}
+}
+
+// PushTransform saves the current transform and then applies the given transform on top of it
+func (renderer *SoftwareRenderer) PushTransform(transform Transform) {
+	renderer.transforms = append(renderer.transforms, renderer.currentTransform().Multiply(transform))
+}
+
+// PopTransform restores the transform saved by the matching call to PushTransform
+func (renderer *SoftwareRenderer) PopTransform() {
+	if len(renderer.transforms) > 1 {
+		renderer.transforms = renderer.transforms[:len(renderer.transforms)-1]
+	}
+}
+
+// PushClip saves the current clip and then restricts drawing to the part of it inside the rectangle
+// described by bounds. Rotated rectangles clip to the pixels around them.
+func (renderer *SoftwareRenderer) PushClip(bounds Bounds) {
+	clip := transformedBounds(renderer.currentTransform(), float64(bounds.X), float64(bounds.Y), float64(bounds.Width), float64(bounds.Height))
+	renderer.clips = append(renderer.clips, clip.Intersect(renderer.currentClip()))
+	renderer.painter.Image = renderer.target()
+}
+
+// PopClip restores the clip saved by the matching call to PushClip
+func (renderer *SoftwareRenderer) PopClip() {
+	if len(renderer.clips) > 1 {
+		renderer.clips = renderer.clips[:len(renderer.clips)-1]
+	}
+	renderer.painter.Image = renderer.target()
+}
+
+// DrawText draws a string with its baseline starting at the given position
+func (renderer *SoftwareRenderer) DrawText(fnt *font.LoadedFont, str string, x float32, y float32, color [4]float32) {
+	glyphs, _ := font.PositionGlyphs(fnt, str)
+	renderer.DrawGlyphs(fnt, glyphs, x, y, color)
+}
+
+// DrawGlyphs draws glyphs that have already been positioned relative to the given position. Unscaled
+// text is copied from the font's glyph atlas, while scaled or rotated text is filled from the glyph
+// outlines so that it stays sharp. Text from distance field fonts is always drawn from the atlas.
+func (renderer *SoftwareRenderer) DrawGlyphs(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32) {
+	renderer.DrawGlyphsWithEffects(fnt, glyphs, x, y, color, font.TextEffects{})
+}
+
+// DrawGlyphsWithEffects draws positioned glyphs like DrawGlyphs, along with their underlines,
+// strikethroughs, shadow and outline. Glows are only drawn around glyphs from distance field fonts.
+func (renderer *SoftwareRenderer) DrawGlyphsWithEffects(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, color [4]float32, effects font.TextEffects) {
+	lines := effects.Lines(fnt, glyphs)
+	// each layer is drawn for every glyph before the next layer starts, so effects around one glyph never
+	// cover another
+	for _, layer := range effects.Layers(color) {
+		renderer.drawLayer(fnt, glyphs, x, y, layer)
+		if layer.Falloff > 0 {
+			continue
+		}
+		for _, line := range lines {
+			line = layer.Grow(line)
+			renderer.FillRect(NewBounds(x+float32(line.X), y+float32(line.Y), float32(line.Width), float32(line.Height)), layer.Color)
+		}
+	}
+}
+
+// drawLayer draws one layer of glyphs. Glyphs without distance fields are drawn at each of the layer's
+// stamps, and when there is more than one they are rasterized together so that overlapping stamps don't
+// darken each other.
+func (renderer *SoftwareRenderer) drawLayer(fnt *font.LoadedFont, glyphs []font.PositionedGlyph, x float32, y float32, layer font.TextLayer) {
+	transform := renderer.currentTransform()
+	if fnt.IsDistanceField() {
+		renderer.drawDistanceField(font.DrawGlyphs(fnt, glyphs, float64(x)+layer.X, float64(y)+layer.Y), layer)
+		return
+	}
+	stamps := layer.Stamps()
+	if len(stamps) == 0 {
+		return
+	}
+	if len(stamps) == 1 && transform.IsTranslation() {
+		src := image.NewUniform(toNRGBA(layer.Color))
+		for _, quad := range font.DrawGlyphs(fnt, glyphs, float64(x+transform[2])+stamps[0].X, float64(y+transform[5])+stamps[0].Y) {
+			dst := image.Rect(int(quad.X), int(quad.Y), int(quad.X)+quad.Source.Dx(), int(quad.Y)+quad.Source.Dy())
+			draw.DrawMask(renderer.target(), dst, src, image.Point{}, quad.Page.Image, quad.Source.Min, draw.Over)
+		}
+		return
+	}
+
+	// every triangle is wound the same way, so rasterizing them together leaves no seams along shared edges
+	for _, stamp := range stamps {
+		triangles := font.GetTrianglesForGlyphs(fnt, glyphs, float64(x)+stamp.X, float64(y)+stamp.Y)
+		for i := 0; i+2 < len(triangles); i += 3 {
+			a := renderer.toFixed(NewPoint(float32(triangles[i].X), float32(triangles[i].Y)))
+			renderer.rasterizer.Start(a)
+			renderer.rasterizer.Add1(renderer.toFixed(NewPoint(float32(triangles[i+1].X), float32(triangles[i+1].Y))))
+			renderer.rasterizer.Add1(renderer.toFixed(NewPoint(float32(triangles[i+2].X), float32(triangles[i+2].Y))))
+			renderer.rasterizer.Add1(a)
+		}
+	}
+	renderer.paint(layer.Color)
+}
+
+// drawDistanceField draws one layer of quads from distance field atlas pages through the current transform
+func (renderer *SoftwareRenderer) drawDistanceField(quads []font.GlyphQuad, layer font.TextLayer) {
+	transform := renderer.currentTransform()
+	inverse, ok := transform.Invert()
+	if !ok {
+		return
+	}
+	// anti-alias over half a pixel on the screen, measured in the font's pixels
+	smoothing := 0.5 / math.Sqrt(math.Abs(float64(transform[0]*transform[4]-transform[1]*transform[3])))
+	fill := layer.Offset == 0 && layer.Falloff == 0
+	for _, quad := range quads {
+		// glyphs from fallback fonts without distance fields can only be filled
+		if quad.Spread == 0 && !fill {
+			continue
+		}
+		width, height := float64(quad.Source.Dx()), float64(quad.Source.Dy())
+		area := transformedBounds(transform, quad.X, quad.Y, width, height).Intersect(renderer.currentClip())
+		for py := area.Min.Y; py < area.Max.Y; py++ {
+			for px := area.Min.X; px < area.Max.X; px++ {
+				point := inverse.Apply(NewPoint(float32(px)+0.5, float32(py)+0.5))
+				u, v := float64(point.X)-quad.X, float64(point.Y)-quad.Y
+				if u < 0 || v < 0 || u >= width || v >= height {
+					continue
+				}
+				value := sampleAlpha(quad.Page.Image, quad.Source, u, v)
+				coverage := value
+				if quad.Spread > 0 {
+					coverage = layer.Coverage(quad.Distance(value), smoothing)
+				}
+				renderer.blend(px, py, layer.Color, coverage)
+			}
+		}
+	}
+}
+
+// blend composites a color over a single pixel of the image, scaling its alpha by coverage
+func (renderer *SoftwareRenderer) blend(x int, y int, color [4]float32, coverage float64) {
+	alpha := math.Max(0, math.Min(1, float64(color[3]))) * coverage
+	if alpha <= 0 {
+		return
+	}
+	offset := renderer.Image.PixOffset(x, y)
+	pix := renderer.Image.Pix[offset : offset+4]
+	for i := 0; i < 3; i++ {
+		channel := math.Max(0, math.Min(1, float64(color[i])))
+		pix[i] = uint8(channel*alpha*255 + float64(pix[i])*(1-alpha) + 0.5)
+	}
+	pix[3] = uint8(alpha*255 + float64(pix[3])*(1-alpha) + 0.5)
+}
+
+// transformedBounds finds the pixels touched by a rectangle after it is mapped through a transform
+func transformedBounds(transform Transform, x float64, y float64, width float64, height float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range []Point{
+		NewPoint(float32(x), float32(y)),
+		NewPoint(float32(x+width), float32(y)),
+		NewPoint(float32(x), float32(y+height)),
+		NewPoint(float32(x+width), float32(y+height)),
+	} {
+		corner = transform.Apply(corner)
+		minX = math.Min(minX, float64(corner.X))
+		minY = math.Min(minY, float64(corner.Y))
+		maxX = math.Max(maxX, float64(corner.X))
+		maxY = math.Max(maxY, float64(corner.Y))
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// sampleAlpha reads a region of an image at a point relative to the region's top left corner, blending
+// between the four closest pixels
+func sampleAlpha(img *image.Alpha, region image.Rectangle, u float64, v float64) float64 {
+	at := func(x int, y int) float64 {
+		x = region.Min.X + int(math.Max(0, math.Min(float64(region.Dx()-1), float64(x))))
+		y = region.Min.Y + int(math.Max(0, math.Min(float64(region.Dy()-1), float64(y))))
+		return float64(img.Pix[img.PixOffset(x, y)]) / 255
+	}
+	u, v = u-0.5, v-0.5
+	x, y := math.Floor(u), math.Floor(v)
+	fx, fy := u-x, v-y
+	ix, iy := int(x), int(y)
+	top := at(ix, iy)*(1-fx) + at(ix+1, iy)*fx
+	bottom := at(ix, iy+1)*(1-fx) + at(ix+1, iy+1)*fx
+	return top*(1-fy) + bottom*fy
+}
+
+// currentTransform gets the transform on the top of the stack
+func (renderer *SoftwareRenderer) currentTransform() Transform {
+	return renderer.transforms[len(renderer.transforms)-1]
+}
+
+// currentClip gets the clip on the top of the stack
+func (renderer *SoftwareRenderer) currentClip() image.Rectangle {
+	return renderer.clips[len(renderer.clips)-1]
+}
+
+// target gets the part of the image inside the current clip, which shares its pixels with the image
+func (renderer *SoftwareRenderer) target() *image.RGBA {
+	return renderer.Image.SubImage(renderer.currentClip()).(*image.RGBA)
+}
+
+// toFixed maps a point through the current transform into the rasterizer's coordinate space
+func (renderer *SoftwareRenderer) toFixed(point Point) fixed.Point26_6 {
+	point = renderer.currentTransform().Apply(point)
+	return fixed.Point26_6{
+		X: fixed.Int26_6(math.Round(float64(point.X) * 64)),
+		Y: fixed.Int26_6(math.Round(float64(point.Y) * 64)),
+	}
+}
+
+// paint composites whatever has been added to the rasterizer onto the image and clears it for the next shape
+func (renderer *SoftwareRenderer) paint(color [4]float32) {
+	renderer.painter.SetColor(toNRGBA(color))
+	renderer.rasterizer.Rasterize(renderer.painter)
+	renderer.rasterizer.Clear()
+}
+
+// toNRGBA converts a floating point color into an 8 bit color
+func toNRGBA(c [4]float32) color.NRGBA {
+	channel := func(value float32) uint8 {
+		return uint8(math.Max(0, math.Min(1, float64(value)))*255 + 0.5)
+	}
+	return color.NRGBA{
+		R: channel(c[0]),
+		G: channel(c[1]),
+		B: channel(c[2]),
+		A: channel(c[3]),
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"image/color"
+	"testing"
+
+	"./font"
+)
+
+func CheckPixel(t *testing.T, renderer *SoftwareRenderer, x int, y int, expected color.RGBA) {
+	if actual := renderer.Image.RGBAAt(x, y); actual != expected {
+		t.Errorf("Invalid pixel at (%d, %d): expected %v, got %v", x, y, expected, actual)
+	}
+}
+
+func TestSoftwareRenderLayout(t *testing.T) {
+	layout := NewTableLayout()
+	CreateRenderableBox(&layout, 20, 10, 0, 0, 1, 1, [4]float32{1, 0, 0, 1})
+	CreateRenderableBox(&layout, 20, 10, 0, 1, 1, 1, [4]float32{0, 0, 1, 1})
+	layout.Layout()
+	renderer := NewSoftwareRenderer(50, 20)
+	layout.Render(renderer)
+	CheckPixel(t, renderer, 0, 0, color.RGBA{255, 0, 0, 255})
+	CheckPixel(t, renderer, 19, 9, color.RGBA{255, 0, 0, 255})
+	CheckPixel(t, renderer, 20, 0, color.RGBA{0, 0, 255, 255})
+	CheckPixel(t, renderer, 39, 9, color.RGBA{0, 0, 255, 255})
+	CheckPixel(t, renderer, 40, 0, color.RGBA{0, 0, 0, 0})
+	CheckPixel(t, renderer, 0, 10, color.RGBA{0, 0, 0, 0})
+}
+
+func TestSoftwareRenderTransform(t *testing.T) {
+	renderer := NewSoftwareRenderer(20, 20)
+	renderer.PushTransform(TranslateTransform(4, 4))
+	renderer.PushTransform(ScaleTransform(2, 2))
+	renderer.FillRect(NewBounds(0, 0, 2.5, 2.5), [4]float32{1, 1, 1, 1})
+	renderer.PopTransform()
+	renderer.PopTransform()
+	renderer.FillRect(NewBounds(0, 0, 1, 1), [4]float32{0, 1, 0, 1})
+	CheckPixel(t, renderer, 0, 0, color.RGBA{0, 255, 0, 255})
+	CheckPixel(t, renderer, 3, 3, color.RGBA{0, 0, 0, 0})
+	CheckPixel(t, renderer, 4, 4, color.RGBA{255, 255, 255, 255})
+	CheckPixel(t, renderer, 8, 8, color.RGBA{255, 255, 255, 255})
+	CheckPixel(t, renderer, 9, 9, color.RGBA{0, 0, 0, 0})
+}
+
+func TestSoftwareRenderClip(t *testing.T) {
+	fnt := LoadTestFont(t, 32)
+	renderer := NewSoftwareRenderer(40, 40)
+	renderer.PushTransform(TranslateTransform(10, 10))
+	renderer.PushClip(NewBounds(0, 0, 20, 20))
+	renderer.PushClip(NewBounds(5, 5, 30, 30))
+	renderer.FillRect(NewBounds(-10, -10, 40, 40), [4]float32{1, 1, 1, 1})
+	renderer.DrawText(fnt, "W", -10, 30, [4]float32{1, 0, 0, 1})
+	renderer.PopClip()
+	renderer.PopClip()
+	renderer.PopTransform()
+	renderer.FillRect(NewBounds(0, 0, 1, 1), [4]float32{0, 1, 0, 1})
+	for y := 1; y < 40; y++ {
+		for x := 1; x < 40; x++ {
+			if inside := x >= 15 && x < 30 && y >= 15 && y < 30; inside != (renderer.Image.RGBAAt(x, y).A > 0) {
+				t.Fatalf("Expected only the overlap of the clips to be drawn, got a mismatch at (%d, %d)", x, y)
+			}
+		}
+	}
+	CheckPixel(t, renderer, 0, 0, color.RGBA{0, 255, 0, 255})
+}
+
+func TestSoftwareRenderText(t *testing.T) {
+	fnt := LoadTestFont(t, 32)
+	renderer := NewSoftwareRenderer(100, 40)
+	renderer.DrawText(fnt, "Il", 10, 30, [4]float32{1, 1, 1, 1})
+	inked := 0
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 100; x++ {
+			if renderer.Image.RGBAAt(x, y).A > 0 {
+				inked++
+				if x < 9 || y > 31 {
+					t.Errorf("Text drawn outside of its box at (%d, %d)", x, y)
+				}
+			}
+		}
+	}
+	if inked == 0 {
+		t.Error("No text was drawn")
+	}
+}
+
+func TestTransformInvert(t *testing.T) {
+	transform := TranslateTransform(4, -2).Multiply(ScaleTransform(2, 0.5))
+	inverse, ok := transform.Invert()
+	if !ok {
+		t.Fatal("Expected the transform to be invertible")
+	}
+	if point := inverse.Apply(transform.Apply(NewPoint(3, 8))); point != NewPoint(3, 8) {
+		t.Errorf("Expected the inverse to undo the transform, got %v", point)
+	}
+	if _, ok := ScaleTransform(0, 1).Invert(); ok {
+		t.Error("Expected a transform that flattens everything not to be invertible")
+	}
+}
+
+func TestSoftwareRenderDistanceField(t *testing.T) {
+	regular := LoadTestFont(t, 32)
+	fnt := font.NewDistanceFieldFont(regular.Font, 32, 4)
+	renderer := NewSoftwareRenderer(100, 100)
+	renderer.PushTransform(ScaleTransform(2, 2))
+	renderer.DrawText(fnt, ".", 2, 40, [4]float32{1, 1, 1, 1})
+	renderer.PopTransform()
+	bounds := regular.GetInkBounds(".")
+	// the middle of the dot is solid and the text around it is empty
+	x := int((2 + bounds.X + bounds.Width/2) * 2)
+	y := int((40 + bounds.Y + bounds.Height/2) * 2)
+	CheckPixel(t, renderer, x, y, color.RGBA{255, 255, 255, 255})
+	CheckPixel(t, renderer, x-int(bounds.Width*2), y, color.RGBA{0, 0, 0, 0})
+	CheckPixel(t, renderer, x+int(bounds.Width*2), y, color.RGBA{0, 0, 0, 0})
+}
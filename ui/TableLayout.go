@@ -2,8 +2,7 @@ package ui
 
 import (
 	"fmt"
-
-	"github.com/go-gl/gl/all-core/gl"
+	"sort"
 
 	"github.com/willauld/lpsimplex"
 )
@@ -97,18 +96,55 @@ func (layout TableLayout) GetMinimumSize() Bounds {
 	return layout.MinSize
 }
 
-// Render draws this component and all of its child components onto the active GL context
-func (layout *TableLayout) Render() {
+// Render draws this component and all of its child components using the renderer
+func (layout *TableLayout) Render(renderer Renderer) {
+	layout.layOutIfNeeded()
+	for _, child := range layout.Children {
+		renderer.PushTransform(TranslateTransform(child.Component.GetBounds().X, child.Component.GetBounds().Y))
+		child.Component.Render(renderer)
+		renderer.PopTransform()
+	}
+}
+
+// GetChildren gets the components in the layout in the order they were added
+func (layout TableLayout) GetChildren() []Component {
+	children := make([]Component, len(layout.Children))
+	for i, child := range layout.Children {
+		children[i] = child.Component
+	}
+	return children
+}
+
+// GetFocusOrder gets the components in the layout row by row from left to right, which is the order focus
+// moves through them in
+func (layout TableLayout) GetFocusOrder() []Component {
+	children := make([]TableLayoutChild, len(layout.Children))
+	copy(children, layout.Children)
+	sort.SliceStable(children, func(i, j int) bool {
+		if children[i].Row != children[j].Row {
+			return children[i].Row < children[j].Row
+		}
+		return children[i].Col < children[j].Col
+	})
+	order := make([]Component, len(children))
+	for i, child := range children {
+		order[i] = child.Component
+	}
+	return order
+}
+
+// ComponentAt finds the deepest component under a point relative to the top left corner of the layout,
+// looking inside any child containers too, and gets the point relative to the top left corner of the
+// component it finds. It finds the layout itself when no child is under the point.
+func (layout *TableLayout) ComponentAt(x float32, y float32) (Component, Point) {
+	return ComponentAt(layout, NewPoint(x, y))
+}
+
+// layOutIfNeeded lays out the children if they have changed since they were last laid out
+func (layout *TableLayout) layOutIfNeeded() {
 	if layout.NeedsLayout {
 		layout.Layout()
 	}
-	for _, child := range layout.Children {
-		gl.PushMatrix()
-		t := [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, child.Component.GetBounds().X, child.Component.GetBounds().Y, 0, 1}
-		gl.MultMatrixf(&t[0])
-		child.Component.Render()
-		gl.PopMatrix()
-	}
 }
 
 // CalculateSmooshedLayout determines the minimum size for either the rows or columns, depending on the arguments passed in
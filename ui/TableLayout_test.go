@@ -21,7 +21,7 @@ func (box Box) GetMinimumSize() Bounds {
 	return box.MinimumSize
 }
 
-func (box Box) Render() {
+func (box Box) Render(renderer Renderer) {
 }
 
 func (box Box) String() string {
@@ -75,3 +75,32 @@ func TestSimpleLayout(t *testing.T) {
 	CheckBox(t, f, 0, 20, 20, 10)
 	CheckBox(t, g, 20, 20, 80, 10)
 }
+
+func TestComponentAt(t *testing.T) {
+	layout := NewTableLayout()
+	a := CreateBox(&layout, 20, 10, 0, 0, 1, 1)
+	inner := NewTableLayout()
+	layout.Add(&inner, 0, 1, 1, 1)
+	b := CreateBox(&inner, 10, 10, 0, 0, 1, 1)
+	c := CreateBox(&inner, 10, 10, 0, 1, 1, 1)
+	layout.Layout()
+	// nested layouts don't report a minimum size yet, so place the inner layout by hand
+	inner.SetBounds(NewBounds(20, 0, 20, 10))
+
+	if component, point := layout.ComponentAt(5, 5); component != a || point != NewPoint(5, 5) {
+		t.Errorf("Expected a at (5, 5), got %v at %v", component, point)
+	}
+	if component, point := layout.ComponentAt(22, 3); component != b || point != NewPoint(2, 3) {
+		t.Errorf("Expected b at (2, 3), got %v at %v", component, point)
+	}
+	if component, point := layout.ComponentAt(35, 9); component != c || point != NewPoint(5, 9) {
+		t.Errorf("Expected c at (5, 9), got %v at %v", component, point)
+	}
+	// right and bottom edges belong to the next component over
+	if component, _ := layout.ComponentAt(30, 0); component != c {
+		t.Errorf("Expected c at its left edge, got %v", component)
+	}
+	if component, point := layout.ComponentAt(50, 20); component != &layout || point != NewPoint(50, 20) {
+		t.Errorf("Expected the layout outside of its children, got %v at %v", component, point)
+	}
+}
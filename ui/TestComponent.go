@@ -1,9 +1,5 @@
 package ui
 
-import (
-	"github.com/go-gl/gl/all-core/gl"
-)
-
 // RenderableBox is a renderable box
 type RenderableBox struct {
 	Bounds      Bounds
@@ -41,13 +37,7 @@ func CreateRenderableBox(layout *TableLayout, width float32, height float32, row
 	return &box
 }
 
-// Render TODO describe
-func (box RenderableBox) Render() {
-	gl.Color4fv(&(box.Color[0]))
-	gl.Begin(gl.QUADS)
-	gl.Vertex2f(0, 0)
-	gl.Vertex2f(0, box.Bounds.Height)
-	gl.Vertex2f(box.Bounds.Width, box.Bounds.Height)
-	gl.Vertex2f(box.Bounds.Width, 0)
-	gl.End()
+// Render fills the box with its color
+func (box RenderableBox) Render(renderer Renderer) {
+	renderer.FillRect(NewBounds(0, 0, box.Bounds.Width, box.Bounds.Height), box.Color)
 }
@@ -0,0 +1,771 @@
+package ui
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"./font"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// TextPosition is a place in the text of a TextArea, given as a line and a byte offset into that line
+type TextPosition struct {
+	Line   int
+	Offset int
+}
+
+// Before determines whether the position comes before another position in the text
+func (position TextPosition) Before(other TextPosition) bool {
+	return position.Line < other.Line || (position.Line == other.Line && position.Offset < other.Offset)
+}
+
+// TextArea is a component that shows and edits many lines of text, wrapping lines that don't fit and
+// scrolling vertically. Lines are only laid out once they are needed, which is usually when they scroll
+// into view, so the area stays fast with thousands of lines in it.
+type TextArea struct {
+	Bounds Bounds
+	Font   *font.LoadedFont
+	Color  [4]float32
+	// SelectionColor fills the background of selected text
+	SelectionColor [4]float32
+	// LineNumbers draws the number of each line in a gutter down the left side in GutterColor
+	LineNumbers bool
+	GutterColor [4]float32
+	// Padding is the space between the edges of the area and its text
+	Padding float32
+	// Columns and Rows are how many digits wide and how many lines tall the area asks to be
+	Columns int
+	Rows    int
+	// ReadOnly stops the text from being edited, while still letting it be selected and copied
+	ReadOnly bool
+	// Clipboard is where text is copied to and pasted from, or nil if the area can't copy and paste
+	Clipboard Clipboard
+	lines     []textAreaLine
+	caret     TextPosition
+	// anchor is the end of the selection that stays put while the caret moves, which is the caret itself
+	// when nothing is selected
+	anchor TextPosition
+	// goalX is where the caret tries to stay horizontally as it moves up and down, or negative if the caret
+	// has moved some other way since
+	goalX float64
+	// top is the first line in view, and scroll is how far down that line the view starts
+	top    int
+	scroll float64
+	// focused is whether the area has keyboard focus, which it only draws the caret with
+	focused bool
+}
+
+// textAreaLine is a line of text between two line breaks, along with its layout once it has been laid out
+// and what it was laid out with
+type textAreaLine struct {
+	text   string
+	layout *font.TextLayout
+	wrap   textAreaWrap
+}
+
+// textAreaWrap is what lines of a text area are laid out with, which they have to be laid out again if
+// any of it changes
+type textAreaWrap struct {
+	font    *font.LoadedFont
+	size    fixed.Int26_6
+	options font.FontOptions
+	width   float64
+}
+
+// NewTextArea creates a new empty text area
+func NewTextArea(fnt *font.LoadedFont, color [4]float32) TextArea {
+	return TextArea{
+		Font:           fnt,
+		Color:          color,
+		SelectionColor: [4]float32{0.2, 0.4, 0.8, 0.6},
+		GutterColor:    [4]float32{0.5, 0.5, 0.5, 1},
+		Padding:        2,
+		Columns:        40,
+		Rows:           10,
+		lines:          []textAreaLine{{}},
+		goalX:          -1,
+	}
+}
+
+// CreateTextArea creates a new text area and adds it to the layout manager
+func CreateTextArea(layout *TableLayout, fnt *font.LoadedFont, color [4]float32, row int, col int, rowSpan int, colSpan int) *TextArea {
+	area := NewTextArea(fnt, color)
+	layout.Add(&area, row, col, rowSpan, colSpan)
+	return &area
+}
+
+// GetText gets all of the text in the area
+func (area TextArea) GetText() string {
+	var text strings.Builder
+	for i, line := range area.lines {
+		if i > 0 {
+			text.WriteByte('\n')
+		}
+		text.WriteString(line.text)
+	}
+	return text.String()
+}
+
+// GetLineCount gets how many lines of text there are, counting each wrapped line once
+func (area TextArea) GetLineCount() int {
+	return len(area.lines)
+}
+
+// GetLine gets the text of a line, without the line break at the end of it
+func (area TextArea) GetLine(line int) string {
+	return area.lines[line].text
+}
+
+// SetText replaces the text in the area, and moves the caret to the end and scrolls to it
+func (area *TextArea) SetText(text string) {
+	area.lines = nil
+	for _, line := range strings.Split(multiLine(text), "\n") {
+		area.lines = append(area.lines, textAreaLine{text: line})
+	}
+	area.top, area.scroll = 0, 0
+	area.moveCaret(area.end(), false)
+}
+
+// AppendText adds text to the end of the area without moving the caret, like a new message arriving in a
+// chat history. If the end of the text was in view, the view follows the text down.
+func (area *TextArea) AppendText(text string) {
+	if len(area.lines) == 0 {
+		area.lines = []textAreaLine{{}}
+	}
+	following := area.showsEnd()
+	added := strings.Split(multiLine(text), "\n")
+	last := &area.lines[len(area.lines)-1]
+	last.text += added[0]
+	last.layout = nil
+	for _, line := range added[1:] {
+		area.lines = append(area.lines, textAreaLine{text: line})
+	}
+	if following {
+		area.top = len(area.lines) - 1
+		area.scroll = area.lineHeight(area.top)
+	}
+	area.normalizeScroll()
+}
+
+// GetCaret gets the position of the caret
+func (area TextArea) GetCaret() TextPosition {
+	return area.caret
+}
+
+// GetSelection gets the start and end of the selected text, which are the same when nothing is selected
+func (area TextArea) GetSelection() (TextPosition, TextPosition) {
+	if area.anchor.Before(area.caret) {
+		return area.anchor, area.caret
+	}
+	return area.caret, area.anchor
+}
+
+// GetSelectedText gets the text between the start and end of the selection
+func (area TextArea) GetSelectedText() string {
+	start, end := area.GetSelection()
+	if start.Line == end.Line {
+		return area.lines[start.Line].text[start.Offset:end.Offset]
+	}
+	text := []string{area.lines[start.Line].text[start.Offset:]}
+	for line := start.Line + 1; line < end.Line; line++ {
+		text = append(text, area.lines[line].text)
+	}
+	return strings.Join(append(text, area.lines[end.Line].text[:end.Offset]), "\n")
+}
+
+// SetCaret moves the caret to the closest place it can stop at or before a position, selecting the text
+// it passes over when extend is true and clearing the selection otherwise
+func (area *TextArea) SetCaret(position TextPosition, extend bool) {
+	position.Line = maxInt(0, minInt(position.Line, len(area.lines)-1))
+	offset := 0
+	for _, stop := range area.stops(position.Line) {
+		if stop <= position.Offset {
+			offset = stop
+		}
+	}
+	area.moveCaret(TextPosition{position.Line, offset}, extend)
+}
+
+// TypeText inserts text at the caret, replacing the selection, unless the area is read only. Tabs become
+// spaces and other control characters besides line breaks are dropped.
+func (area *TextArea) TypeText(text string) {
+	if area.ReadOnly {
+		return
+	}
+	area.replaceSelection(multiLine(text))
+}
+
+// ScrollBy moves the view down by a distance, or up when the distance is negative, without scrolling past
+// either end of the text
+func (area *TextArea) ScrollBy(distance float64) {
+	area.scroll += distance
+	area.normalizeScroll()
+}
+
+// PressKey moves the caret or edits the text in response to a key press, and returns whether the area
+// used the key
+func (area *TextArea) PressKey(key Key, mods Modifiers) bool {
+	extend := mods&ModShift != 0
+	start, end := area.GetSelection()
+	goalX := area.goalX
+	switch key {
+	case KeyLeft:
+		if start != end && !extend {
+			area.moveCaret(start, false)
+		} else if mods.IsShortcut() && area.caret.Offset > 0 {
+			area.moveCaret(TextPosition{area.caret.Line, previousWord(area.lines[area.caret.Line].text, area.caret.Offset)}, extend)
+		} else {
+			area.moveCaret(area.previousPosition(area.caret), extend)
+		}
+	case KeyRight:
+		if start != end && !extend {
+			area.moveCaret(end, false)
+		} else if mods.IsShortcut() && area.caret.Offset < len(area.lines[area.caret.Line].text) {
+			area.moveCaret(TextPosition{area.caret.Line, nextWord(area.lines[area.caret.Line].text, area.caret.Offset)}, extend)
+		} else {
+			area.moveCaret(area.nextPosition(area.caret), extend)
+		}
+	case KeyUp, KeyDown, KeyPageUp, KeyPageDown:
+		rows := 1
+		if key == KeyPageUp || key == KeyPageDown {
+			rows = maxInt(1, int(area.viewHeight()/area.Font.GetLineHeight()))
+		}
+		direction := 1
+		if key == KeyUp || key == KeyPageUp {
+			direction = -1
+		}
+		if goalX < 0 {
+			goalX = area.caretX(area.caret)
+		}
+		position := area.caret
+		for i := 0; i < rows; i++ {
+			position = area.verticalPosition(position, direction, goalX)
+		}
+		if rows > 1 {
+			area.ScrollBy(float64(direction*rows) * area.Font.GetLineHeight())
+		}
+		area.moveCaret(position, extend)
+		area.goalX = goalX
+	case KeyHome:
+		if mods.IsShortcut() {
+			area.moveCaret(TextPosition{}, extend)
+		} else {
+			carets := area.rowCarets(area.caret.Line, area.rowOf(area.caret))
+			area.moveCaret(TextPosition{area.caret.Line, carets[0].Offset}, extend)
+		}
+	case KeyEnd:
+		if mods.IsShortcut() {
+			area.moveCaret(area.end(), extend)
+		} else {
+			carets := area.rowCarets(area.caret.Line, area.rowOf(area.caret))
+			area.moveCaret(TextPosition{area.caret.Line, carets[len(carets)-1].Offset}, extend)
+		}
+	case KeyBackspace, KeyDelete:
+		if area.ReadOnly {
+			return false
+		}
+		if start == end {
+			area.anchor = area.deletionEnd(key == KeyDelete, mods.IsShortcut())
+		}
+		area.replaceSelection("")
+	case KeyEnter:
+		if area.ReadOnly {
+			return false
+		}
+		area.replaceSelection("\n")
+	case KeyA:
+		if !mods.IsShortcut() {
+			return false
+		}
+		area.anchor = TextPosition{}
+		area.moveCaret(area.end(), true)
+	case KeyC, KeyX:
+		if !mods.IsShortcut() || (key == KeyX && area.ReadOnly) {
+			return false
+		}
+		if area.Clipboard != nil && start != end {
+			area.Clipboard.SetClipboardString(area.GetSelectedText())
+			if key == KeyX {
+				area.replaceSelection("")
+			}
+		}
+	case KeyV:
+		if !mods.IsShortcut() || area.ReadOnly {
+			return false
+		}
+		if area.Clipboard != nil {
+			if text, err := area.Clipboard.GetClipboardString(); err == nil {
+				area.TypeText(text)
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// PressMouse moves the caret to the closest place to a point inside the area, selecting the text between
+// it and the old caret when shift is held
+func (area *TextArea) PressMouse(point Point, mods Modifiers) {
+	area.moveCaret(area.positionAt(point), mods&ModShift != 0)
+}
+
+// DragMouse selects the text between where the mouse was pressed and a point inside the area, scrolling
+// when the point is above or below it
+func (area *TextArea) DragMouse(point Point) {
+	area.moveCaret(area.positionAt(point), true)
+}
+
+// HandleEvent edits the text, moves the caret and scrolls in response to input sent to the area
+func (area *TextArea) HandleEvent(event *Event) {
+	if event.Phase != PhaseTarget {
+		return
+	}
+	switch event.Type {
+	case MouseDownEvent:
+		if event.Button == MouseLeft {
+			area.PressMouse(event.Point, event.Mods)
+			event.StopPropagation()
+		}
+	case MouseMoveEvent:
+		if event.Buttons&MouseLeft != 0 {
+			area.DragMouse(event.Point)
+			event.StopPropagation()
+		}
+	case MouseScrollEvent:
+		area.ScrollBy(-float64(event.Scroll.Y) * 3 * area.Font.GetLineHeight())
+		event.StopPropagation()
+	case KeyDownEvent:
+		if area.PressKey(event.Key, event.Mods) {
+			event.StopPropagation()
+		}
+	case TextInputEvent:
+		if !area.ReadOnly {
+			area.TypeText(event.Text)
+			event.StopPropagation()
+		}
+	}
+}
+
+// SetFocused sets whether the area has keyboard focus
+func (area *TextArea) SetFocused(focused bool) {
+	area.focused = focused
+}
+
+// GetBounds determines the bounds of the component
+func (area TextArea) GetBounds() Bounds {
+	return area.Bounds
+}
+
+// SetBounds sets the bounds of the component
+func (area *TextArea) SetBounds(bounds Bounds) {
+	area.Bounds = bounds
+	area.normalizeScroll()
+}
+
+// GetMinimumSize determines the minimum size of the component, which fits Rows lines of text as wide as
+// Columns digits along with the gutter
+func (area TextArea) GetMinimumSize() Bounds {
+	width := float64(area.Columns)*area.Font.GetAdvance("0") + float64(area.gutterWidth()+2*area.Padding)
+	height := float64(area.Rows)*area.Font.GetLineHeight() + float64(2*area.Padding)
+	return NewBounds(0, 0, float32(math.Ceil(width)), float32(math.Ceil(height)))
+}
+
+// Render draws the lines in view, along with their line numbers, the selection and the caret
+func (area TextArea) Render(renderer Renderer) {
+	renderer.PushClip(NewBounds(0, 0, area.Bounds.Width, area.Bounds.Height))
+	defer renderer.PopClip()
+
+	gutter := area.gutterWidth()
+	left := gutter + area.Padding
+	rowHeight := area.Font.GetLineHeight()
+	y := float64(area.Padding) - area.scroll
+	for line := area.top; line < len(area.lines) && y < float64(area.Bounds.Height); line++ {
+		layout := area.layout(line)
+		top := float32(math.Round(y))
+		if area.LineNumbers {
+			number := strconv.Itoa(line + 1)
+			x := gutter - area.Padding - float32(area.Font.GetAdvance(number))
+			renderer.DrawText(area.Font, number, x, top+float32(area.Font.GetAscent()), area.GutterColor)
+		}
+		area.renderSelection(renderer, line, left, top)
+		renderer.DrawGlyphs(area.Font, layout.GetGlyphs(), left, top, area.Color)
+		if area.focused && line == area.caret.Line {
+			x := float32(math.Round(float64(left) + area.caretX(area.caret)))
+			rowTop := top + float32(float64(area.rowOf(area.caret))*rowHeight)
+			renderer.FillRect(NewBounds(x, rowTop, 1, float32(rowHeight)), area.Color)
+		}
+		y += area.lineHeight(line)
+	}
+}
+
+// renderSelection fills behind the selected clusters of a line, along with the line break at the end of it
+// when that is selected too
+func (area TextArea) renderSelection(renderer Renderer, line int, left float32, top float32) {
+	start, end := area.GetSelection()
+	if line < start.Line || line > end.Line || start == end {
+		return
+	}
+	rowHeight := area.Font.GetLineHeight()
+	for r, row := range area.layout(line).Lines {
+		rowTop := top + float32(float64(r)*rowHeight)
+		clusters := map[int]bool{}
+		for _, caret := range row.Carets {
+			clusters[caret.Offset] = true
+		}
+		for _, glyph := range row.Glyphs {
+			position := TextPosition{line, glyph.Offset}
+			if position.Before(start) || !position.Before(end) || !clusters[glyph.Offset] {
+				continue
+			}
+			x0 := math.Round(float64(left) + glyph.X)
+			x1 := math.Round(float64(left) + glyph.X + glyph.Font.GetGlyph(glyph.Index).AdvanceWidth)
+			renderer.FillRect(NewBounds(float32(x0), rowTop, float32(x1-x0), float32(rowHeight)), area.SelectionColor)
+		}
+	}
+	if line < end.Line {
+		rows := area.layout(line).Lines
+		last := rows[len(rows)-1]
+		x := float32(math.Round(float64(left) + last.Carets[len(last.Carets)-1].X))
+		rowTop := top + float32(float64(len(rows)-1)*rowHeight)
+		renderer.FillRect(NewBounds(x, rowTop, float32(math.Ceil(area.Font.GetAdvance(" "))), float32(rowHeight)), area.SelectionColor)
+	}
+}
+
+// layout gets the layout of a line, laying it out if it hasn't been laid out since it or the area last
+// changed. Lines are shared between copies of the area, so a copy can lay them out while drawing.
+func (area TextArea) layout(line int) *font.TextLayout {
+	wrap := textAreaWrap{font: area.Font, size: area.Font.Size, options: area.Font.Options, width: area.textWidth()}
+	if area.lines[line].layout == nil || area.lines[line].wrap != wrap {
+		area.lines[line].layout = font.LayoutText(area.Font, area.lines[line].text, font.LayoutOptions{MaxWidth: wrap.width})
+		area.lines[line].wrap = wrap
+	}
+	return area.lines[line].layout
+}
+
+// lineHeight gets the height of a line once it has been wrapped
+func (area TextArea) lineHeight(line int) float64 {
+	return float64(len(area.layout(line).Lines)) * area.Font.GetLineHeight()
+}
+
+// gutterWidth gets the width of the gutter the line numbers are drawn in, which fits the largest number
+func (area TextArea) gutterWidth() float32 {
+	if !area.LineNumbers {
+		return 0
+	}
+	digits := len(strconv.Itoa(len(area.lines)))
+	return float32(math.Ceil(float64(digits)*area.Font.GetAdvance("0"))) + 2*area.Padding
+}
+
+// textWidth gets the width lines are wrapped to fit in, or zero to not wrap them before the area has a size
+func (area TextArea) textWidth() float64 {
+	if area.Bounds.Width <= 0 {
+		return 0
+	}
+	return math.Max(1, float64(area.Bounds.Width-area.gutterWidth()-2*area.Padding))
+}
+
+// viewHeight gets the height of the part of the area text is drawn in
+func (area TextArea) viewHeight() float64 {
+	return float64(area.Bounds.Height - 2*area.Padding)
+}
+
+// end gets the position at the end of the text
+func (area TextArea) end() TextPosition {
+	last := len(area.lines) - 1
+	return TextPosition{last, len(area.lines[last].text)}
+}
+
+// stops gets the byte offsets the caret can stop at in a line in order, which skip over combining marks
+// and the middle of anything else that is drawn as a single glyph
+func (area *TextArea) stops(line int) []int {
+	var stops []int
+	for _, row := range area.layout(line).Lines {
+		for _, caret := range row.Carets {
+			if len(stops) == 0 || caret.Offset > stops[len(stops)-1] {
+				stops = append(stops, caret.Offset)
+			}
+		}
+	}
+	return stops
+}
+
+// rowOf finds which of the wrapped rows of its line a position is on. Positions where a line was wrapped
+// are at the start of the next row.
+func (area TextArea) rowOf(position TextPosition) int {
+	row := 0
+	for r, line := range area.layout(position.Line).Lines {
+		if line.Start <= position.Offset {
+			row = r
+		}
+	}
+	return row
+}
+
+// rowCarets gets the carets of a wrapped row of a line, leaving off the caret at the end of rows that were
+// wrapped since that position belongs to the next row
+func (area TextArea) rowCarets(line int, row int) []font.Caret {
+	rows := area.layout(line).Lines
+	carets := rows[row].Carets
+	if row < len(rows)-1 && len(carets) > 1 {
+		carets = carets[:len(carets)-1]
+	}
+	return carets
+}
+
+// caretX gets how far from the left edge of the text the caret is drawn at a position
+func (area TextArea) caretX(position TextPosition) float64 {
+	x := 0.0
+	for _, caret := range area.layout(position.Line).Lines[area.rowOf(position)].Carets {
+		if caret.Offset <= position.Offset {
+			x = caret.X
+		}
+	}
+	return x
+}
+
+// closestCaret gets the byte offset of the caret in a row of a line that is closest to x
+func (area *TextArea) closestCaret(line int, row int, x float64) int {
+	carets := area.rowCarets(line, row)
+	best := carets[0]
+	for _, caret := range carets {
+		if math.Abs(caret.X-x) < math.Abs(best.X-x) {
+			best = caret
+		}
+	}
+	return best.Offset
+}
+
+// previousPosition gets the place the caret stops at before a position, which is the end of the line
+// before at the start of a line
+func (area *TextArea) previousPosition(position TextPosition) TextPosition {
+	if position.Offset == 0 {
+		if position.Line == 0 {
+			return position
+		}
+		return TextPosition{position.Line - 1, len(area.lines[position.Line-1].text)}
+	}
+	previous := 0
+	for _, stop := range area.stops(position.Line) {
+		if stop < position.Offset {
+			previous = stop
+		}
+	}
+	return TextPosition{position.Line, previous}
+}
+
+// nextPosition gets the place the caret stops at after a position, which is the start of the next line
+// at the end of a line
+func (area *TextArea) nextPosition(position TextPosition) TextPosition {
+	for _, stop := range area.stops(position.Line) {
+		if stop > position.Offset {
+			return TextPosition{position.Line, stop}
+		}
+	}
+	if position.Line == len(area.lines)-1 {
+		return position
+	}
+	return TextPosition{position.Line + 1, 0}
+}
+
+// verticalPosition gets the position on the row above or below a position that is closest to goalX. Moving
+// up from the first row goes to the start of the text, and moving down from the last row goes to the end.
+func (area *TextArea) verticalPosition(position TextPosition, direction int, goalX float64) TextPosition {
+	line, row := position.Line, area.rowOf(position)+direction
+	if row < 0 {
+		if line == 0 {
+			return TextPosition{}
+		}
+		line--
+		row = len(area.layout(line).Lines) - 1
+	} else if row >= len(area.layout(line).Lines) {
+		if line == len(area.lines)-1 {
+			return area.end()
+		}
+		line, row = line+1, 0
+	}
+	return TextPosition{line, area.closestCaret(line, row, goalX)}
+}
+
+// deletionEnd gets where the text removed by backspace or delete ends, on the other side of the caret.
+// Backspace removes one rune at a time so that marks can be taken off of letters, while delete removes
+// whole clusters, and either removes a word at a time as a shortcut.
+func (area *TextArea) deletionEnd(forwards bool, word bool) TextPosition {
+	text := area.lines[area.caret.Line].text
+	switch {
+	case forwards && word && area.caret.Offset < len(text):
+		return TextPosition{area.caret.Line, nextWord(text, area.caret.Offset)}
+	case forwards:
+		return area.nextPosition(area.caret)
+	case word && area.caret.Offset > 0:
+		return TextPosition{area.caret.Line, previousWord(text, area.caret.Offset)}
+	case area.caret.Offset > 0:
+		_, size := utf8.DecodeLastRuneInString(text[:area.caret.Offset])
+		return TextPosition{area.caret.Line, area.caret.Offset - size}
+	}
+	return area.previousPosition(area.caret)
+}
+
+// positionAt gets the position of the caret closest to a point inside the area
+func (area *TextArea) positionAt(point Point) TextPosition {
+	x := float64(point.X - area.gutterWidth() - area.Padding)
+	y := float64(point.Y-area.Padding) + area.scroll
+	line := area.top
+	for y < 0 && line > 0 {
+		line--
+		y += area.lineHeight(line)
+	}
+	for y >= area.lineHeight(line) && line < len(area.lines)-1 {
+		y -= area.lineHeight(line)
+		line++
+	}
+	rows := len(area.layout(line).Lines)
+	row := maxInt(0, minInt(rows-1, int(math.Floor(y/area.Font.GetLineHeight()))))
+	return TextPosition{line, area.closestCaret(line, row, x)}
+}
+
+// moveCaret moves the caret to a position it can stop at and scrolls it into view
+func (area *TextArea) moveCaret(position TextPosition, extend bool) {
+	area.caret = position
+	if !extend {
+		area.anchor = position
+	}
+	area.goalX = -1
+	area.scrollToCaret()
+}
+
+// replaceSelection replaces the selected text with text that may contain line breaks, leaving the caret
+// after it
+func (area *TextArea) replaceSelection(text string) {
+	if len(area.lines) == 0 {
+		area.lines = []textAreaLine{{}}
+	}
+	start, end := area.GetSelection()
+	before := area.lines[start.Line].text[:start.Offset]
+	after := area.lines[end.Line].text[end.Offset:]
+	inserted := strings.Split(text, "\n")
+	last := len(inserted) - 1
+	caret := TextPosition{start.Line + last, len(inserted[last])}
+	if last == 0 {
+		caret.Offset += len(before)
+	}
+	replacement := make([]textAreaLine, len(inserted))
+	for i, line := range inserted {
+		replacement[i].text = line
+	}
+	replacement[0].text = before + replacement[0].text
+	replacement[last].text += after
+	area.lines = append(area.lines[:start.Line], append(replacement, area.lines[end.Line+1:]...)...)
+	if area.top >= len(area.lines) {
+		area.top, area.scroll = len(area.lines)-1, 0
+	}
+	area.normalizeScroll()
+	area.moveCaret(caret, false)
+}
+
+// scrollToCaret scrolls as little as possible to bring the row the caret is on into view. Only the lines
+// between the view and the caret are laid out, so jumping to the far end of the text stays fast.
+func (area *TextArea) scrollToCaret() {
+	view := area.viewHeight()
+	if area.Font == nil || view <= 0 {
+		return
+	}
+	rowHeight := area.Font.GetLineHeight()
+	rowTop := float64(area.rowOf(area.caret)) * rowHeight
+	if area.caret.Line < area.top || (area.caret.Line == area.top && rowTop < area.scroll) {
+		area.top, area.scroll = area.caret.Line, rowTop
+		return
+	}
+	// find where the view would start with the caret's row at the bottom, working up from the caret
+	top, scroll := area.caret.Line, rowTop+rowHeight-view
+	for scroll < 0 && top > 0 {
+		top--
+		scroll += area.lineHeight(top)
+	}
+	if top > area.top || (top == area.top && scroll > area.scroll) {
+		area.top, area.scroll = top, scroll
+	}
+}
+
+// normalizeScroll moves the view so that it starts within its top line, and pulls it back up so that it
+// doesn't leave space below the end of the text
+func (area *TextArea) normalizeScroll() {
+	if area.Font == nil || area.viewHeight() <= 0 {
+		return
+	}
+	area.settleTop()
+	below := -area.scroll
+	for line := area.top; line < len(area.lines) && below < area.viewHeight(); line++ {
+		below += area.lineHeight(line)
+	}
+	if below < area.viewHeight() {
+		area.scroll -= area.viewHeight() - below
+		area.settleTop()
+	}
+	if area.top == 0 {
+		area.scroll = math.Max(0, area.scroll)
+	}
+}
+
+// settleTop changes which line is at the top of the view until the view starts within it
+func (area *TextArea) settleTop() {
+	for area.scroll < 0 && area.top > 0 {
+		area.top--
+		area.scroll += area.lineHeight(area.top)
+	}
+	for area.top < len(area.lines)-1 && area.scroll >= area.lineHeight(area.top) {
+		area.scroll -= area.lineHeight(area.top)
+		area.top++
+	}
+}
+
+// showsEnd determines whether the end of the text is in view
+func (area *TextArea) showsEnd() bool {
+	if area.Font == nil || area.viewHeight() <= 0 {
+		return true
+	}
+	bottom := -area.scroll
+	for line := area.top; line < len(area.lines); line++ {
+		if bottom >= area.viewHeight() {
+			return false
+		}
+		bottom += area.lineHeight(line)
+	}
+	return bottom <= area.viewHeight()
+}
+
+// multiLine turns tabs into spaces, normalizes line breaks to \n and drops other control characters
+func multiLine(text string) string {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	return strings.Map(func(char rune) rune {
+		switch {
+		case char == '\n' || char == '\r':
+			return '\n'
+		case char == '\t':
+			return ' '
+		case unicode.IsControl(char):
+			return -1
+		}
+		return char
+	}, text)
+}
+
+// minInt finds the smaller of two integers
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt finds the larger of two integers
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"./font"
+)
+
+func CheckArea(t *testing.T, area *TextArea, text string, start TextPosition, end TextPosition) {
+	t.Helper()
+	if area.GetText() != text {
+		t.Errorf("Invalid text: expected %q, got %q", text, area.GetText())
+	}
+	if s, e := area.GetSelection(); s != start || e != end {
+		t.Errorf("Invalid selection: expected %v to %v, got %v to %v", start, end, s, e)
+	}
+}
+
+func TestTextAreaEditing(t *testing.T) {
+	area := NewTextArea(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	area.SetBounds(NewBounds(0, 0, 300, 100))
+	area.TypeText("Olympus\r\nMons\tvolcano")
+	CheckArea(t, &area, "Olympus\nMons volcano", TextPosition{1, 12}, TextPosition{1, 12})
+	area.PressKey(KeyHome, 0)
+	area.PressKey(KeyLeft, ModShift)
+	CheckArea(t, &area, "Olympus\nMons volcano", TextPosition{0, 7}, TextPosition{1, 0})
+	if area.GetSelectedText() != "\n" {
+		t.Errorf("Expected the line break to be selected, got %q", area.GetSelectedText())
+	}
+	area.PressKey(KeyBackspace, 0)
+	CheckArea(t, &area, "OlympusMons volcano", TextPosition{0, 7}, TextPosition{0, 7})
+	area.PressKey(KeyEnter, 0)
+	area.PressKey(KeyEnter, 0)
+	CheckArea(t, &area, "Olympus\n\nMons volcano", TextPosition{2, 0}, TextPosition{2, 0})
+	area.PressKey(KeyBackspace, 0)
+	area.PressKey(KeyBackspace, 0)
+	CheckArea(t, &area, "OlympusMons volcano", TextPosition{0, 7}, TextPosition{0, 7})
+	area.PressKey(KeyEnd, ModControl|ModShift)
+	area.TypeText("\nTharsis")
+	CheckArea(t, &area, "Olympus\nTharsis", TextPosition{1, 7}, TextPosition{1, 7})
+	area.PressKey(KeyHome, ModControl)
+	area.PressKey(KeyRight, ModControl|ModShift)
+	CheckArea(t, &area, "Olympus\nTharsis", TextPosition{0, 0}, TextPosition{0, 7})
+	area.PressKey(KeyRight, ModShift)
+	area.PressKey(KeyRight, ModShift)
+	CheckArea(t, &area, "Olympus\nTharsis", TextPosition{0, 0}, TextPosition{1, 1})
+	area.PressKey(KeyRight, 0)
+	CheckArea(t, &area, "Olympus\nTharsis", TextPosition{1, 1}, TextPosition{1, 1})
+	if area.PressKey(KeyC, 0) || area.PressKey(KeyUnknown, 0) {
+		t.Error("Expected the area to ignore keys it doesn't use")
+	}
+}
+
+func TestTextAreaVerticalMovement(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	area := NewTextArea(fnt, [4]float32{1, 1, 1, 1})
+	area.SetBounds(NewBounds(0, 0, 300, 100))
+	area.SetText("Phobos\nIo\nDeimos")
+	area.SetCaret(TextPosition{0, 5}, false)
+	// the caret keeps to the same place across lines too short to reach it
+	area.PressKey(KeyDown, 0)
+	CheckArea(t, &area, "Phobos\nIo\nDeimos", TextPosition{1, 2}, TextPosition{1, 2})
+	area.PressKey(KeyDown, ModShift)
+	start, end := area.GetSelection()
+	if start != (TextPosition{1, 2}) || end.Line != 2 || end.Offset < 4 {
+		t.Errorf("Expected the caret to return to its column, got %v to %v", start, end)
+	}
+	area.PressKey(KeyDown, 0)
+	CheckArea(t, &area, "Phobos\nIo\nDeimos", TextPosition{2, 6}, TextPosition{2, 6})
+	area.PressKey(KeyUp, 0)
+	area.PressKey(KeyUp, 0)
+	area.PressKey(KeyUp, 0)
+	CheckArea(t, &area, "Phobos\nIo\nDeimos", TextPosition{0, 0}, TextPosition{0, 0})
+}
+
+func TestTextAreaZeroValue(t *testing.T) {
+	area := TextArea{Font: LoadTestFont(t, 16)}
+	area.SetBounds(NewBounds(0, 0, 100, 50))
+	area.AppendText("Gale\nSharp")
+	area.Render(NewSoftwareRenderer(100, 50))
+	if area.GetText() != "Gale\nSharp" {
+		t.Errorf("Expected the text to be appended, got %q", area.GetText())
+	}
+	area = TextArea{Font: LoadTestFont(t, 16)}
+	area.TypeText("Curiosity")
+	CheckArea(t, &area, "Curiosity", TextPosition{0, 9}, TextPosition{0, 9})
+}
+
+func TestTextAreaWrapping(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	area := NewTextArea(fnt, [4]float32{1, 1, 1, 1})
+	width := float32(fnt.GetAdvance("Valles Marineris")) + 2*area.Padding
+	area.SetBounds(NewBounds(0, 0, width, 100))
+	area.SetText("Valles Marineris canyon")
+	if rows := len(area.layout(0).Lines); rows != 2 {
+		t.Fatalf("Expected the line to wrap onto 2 rows, got %d", rows)
+	}
+	// home and end stay on the wrapped row the caret is on
+	area.PressKey(KeyHome, 0)
+	CheckArea(t, &area, "Valles Marineris canyon", TextPosition{0, 17}, TextPosition{0, 17})
+	area.PressKey(KeyUp, 0)
+	area.PressKey(KeyEnd, 0)
+	if caret := area.GetCaret(); caret.Offset >= 17 || area.rowOf(caret) != 0 {
+		t.Errorf("Expected the caret at the end of the first row, got %v", caret)
+	}
+	// making the area wider lays the line out again without wrapping
+	area.SetBounds(NewBounds(0, 0, 400, 100))
+	wide := area.layout(0)
+	if rows := len(wide.Lines); rows != 1 {
+		t.Errorf("Expected the line to fit on 1 row, got %d", rows)
+	}
+	fnt.SetOptions(font.FontOptions{SubpixelPositions: 4})
+	if area.layout(0) == wide {
+		t.Error("Expected the line to be laid out again with the new font options")
+	}
+}
+
+func TestTextAreaScroll(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	area := NewTextArea(fnt, [4]float32{1, 1, 1, 1})
+	area.SetBounds(NewBounds(0, 0, 200, float32(fnt.GetLineHeight()*3)+2*area.Padding))
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, "Line "+strconv.Itoa(i))
+	}
+	area.SetText(strings.Join(lines, "\n"))
+	if area.top != 7 || area.scroll != 0 {
+		t.Errorf("Expected the last 3 lines to be in view, got line %d scrolled by %f", area.top, area.scroll)
+	}
+	area.ScrollBy(1000)
+	if area.top != 7 || area.scroll != 0 {
+		t.Errorf("Expected scrolling to stop at the end, got line %d scrolled by %f", area.top, area.scroll)
+	}
+	area.ScrollBy(-fnt.GetLineHeight() * 2.5)
+	if area.top != 4 || area.scroll != fnt.GetLineHeight()/2 {
+		t.Errorf("Expected to scroll up 2.5 lines, got line %d scrolled by %f", area.top, area.scroll)
+	}
+	area.ScrollBy(-1000)
+	if area.top != 0 || area.scroll != 0 {
+		t.Errorf("Expected scrolling to stop at the start, got line %d scrolled by %f", area.top, area.scroll)
+	}
+	// clicking finds lines that have been scrolled into view
+	area.ScrollBy(fnt.GetLineHeight() * 2)
+	area.PressMouse(NewPoint(area.Padding, area.Padding+1), 0)
+	CheckArea(t, &area, area.GetText(), TextPosition{2, 0}, TextPosition{2, 0})
+	area.PressKey(KeyPageDown, 0)
+	if caret := area.GetCaret(); caret.Line != 5 || area.top != 5 {
+		t.Errorf("Expected page down to move 3 lines, got the caret on %d with line %d at the top", caret.Line, area.top)
+	}
+}
+
+func TestTextAreaReadOnly(t *testing.T) {
+	area := NewTextArea(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	area.SetText("Mars\nAres")
+	area.ReadOnly = true
+	clipboard := &testClipboard{text: "Earth"}
+	area.Clipboard = clipboard
+	area.TypeText("Venus")
+	if area.PressKey(KeyEnter, 0) || area.PressKey(KeyBackspace, 0) || area.PressKey(KeyV, ModControl) || area.PressKey(KeyX, ModControl) {
+		t.Error("Expected a read only area to ignore editing keys")
+	}
+	area.PressKey(KeyA, ModControl)
+	area.PressKey(KeyC, ModControl)
+	CheckArea(t, &area, "Mars\nAres", TextPosition{0, 0}, TextPosition{1, 4})
+	if clipboard.text != "Mars\nAres" {
+		t.Errorf("Expected the text to be copied, got %q", clipboard.text)
+	}
+}
+
+func TestTextAreaManyLines(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	area := NewTextArea(fnt, [4]float32{1, 1, 1, 1})
+	area.LineNumbers = true
+	area.SetBounds(NewBounds(0, 0, 300, 100))
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, "Message "+strconv.Itoa(i))
+	}
+	area.SetText(strings.Join(lines, "\n"))
+	area.Render(NewSoftwareRenderer(300, 100))
+	area.AppendText("\nMessage 5000")
+	if area.GetLineCount() != 5001 || !area.showsEnd() {
+		t.Errorf("Expected the view to follow new lines, got %d lines", area.GetLineCount())
+	}
+	area.PressKey(KeyHome, ModControl)
+	area.Render(NewSoftwareRenderer(300, 100))
+	laidOut := 0
+	for _, line := range area.lines {
+		if line.layout != nil {
+			laidOut++
+		}
+	}
+	if laidOut == 0 || laidOut > 20 {
+		t.Errorf("Expected only lines near the view to be laid out, got %d", laidOut)
+	}
+	// new lines don't move the view when it isn't at the end
+	area.AppendText("\nMessage 5001")
+	if area.top != 0 || area.scroll != 0 {
+		t.Errorf("Expected the view to stay at the start, got line %d scrolled by %f", area.top, area.scroll)
+	}
+}
+
+func TestGoldenTextArea(t *testing.T) {
+	area := NewTextArea(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	area.LineNumbers = true
+	area.SetFocused(true)
+	area.SetBounds(NewBounds(0, 0, 160, 100))
+	area.SetText("> spawn rover\nRover deployed at the edge of Jezero crater\n> status")
+	area.SetCaret(TextPosition{1, 6}, false)
+	area.PressKey(KeyDown, ModShift)
+	CheckGolden(t, "text_area", &area, 160, 100, 8)
+}
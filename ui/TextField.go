@@ -0,0 +1,440 @@
+package ui
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"./font"
+)
+
+// passwordMask is the rune drawn in place of every rune of a password
+const passwordMask = '\u2022'
+
+// TextField is a component that edits a single line of text. The caret and selection are tracked as byte
+// offsets into the text, and the text scrolls sideways to keep the caret in view when it doesn't fit.
+type TextField struct {
+	Bounds Bounds
+	Font   *font.LoadedFont
+	Color  [4]float32
+	// SelectionColor fills the background of selected text
+	SelectionColor [4]float32
+	// Padding is the space between the edges of the field and its text
+	Padding float32
+	// Columns is how many digits wide the field asks to be
+	Columns int
+	// MaxLength is the most runes the text can hold, or zero for no limit
+	MaxLength int
+	// Password draws a bullet in place of every rune and stops the text from being copied
+	Password bool
+	// Clipboard is where text is copied to and pasted from, or nil if the field can't copy and paste
+	Clipboard Clipboard
+	// OnSubmit is called with the text when enter is pressed, if it is set
+	OnSubmit func(text string)
+	text     string
+	caret    int
+	// anchor is the end of the selection that stays put while the caret moves, which is the caret itself
+	// when nothing is selected
+	anchor int
+	// scroll is how far the text has been moved to the left to keep the caret in view
+	scroll float64
+	// focused is whether the field has keyboard focus, which it only draws the caret with
+	focused bool
+}
+
+// NewTextField creates a new empty text field
+func NewTextField(fnt *font.LoadedFont, color [4]float32) TextField {
+	return TextField{
+		Font:           fnt,
+		Color:          color,
+		SelectionColor: [4]float32{0.2, 0.4, 0.8, 0.6},
+		Padding:        2,
+		Columns:        20,
+	}
+}
+
+// CreateTextField creates a new text field and adds it to the layout manager
+func CreateTextField(layout *TableLayout, fnt *font.LoadedFont, color [4]float32, row int, col int, rowSpan int, colSpan int) *TextField {
+	field := NewTextField(fnt, color)
+	layout.Add(&field, row, col, rowSpan, colSpan)
+	return &field
+}
+
+// GetText gets the text in the field
+func (field TextField) GetText() string {
+	return field.text
+}
+
+// SetText replaces the text in the field, cutting it short if it is longer than the maximum length, and
+// moves the caret to the end
+func (field *TextField) SetText(text string) {
+	field.text = field.limit(singleLine(text), utf8.RuneCountInString(field.text))
+	field.SetCaret(len(field.text), false)
+}
+
+// GetCaret gets the byte offset of the caret in the text
+func (field TextField) GetCaret() int {
+	return field.caret
+}
+
+// GetSelection gets the byte offsets of the start and end of the selected text, which are the same when
+// nothing is selected
+func (field TextField) GetSelection() (int, int) {
+	if field.anchor < field.caret {
+		return field.anchor, field.caret
+	}
+	return field.caret, field.anchor
+}
+
+// SetCaret moves the caret to the closest place it can stop at or before a byte offset, selecting the text
+// it passes over when extend is true and clearing the selection otherwise
+func (field *TextField) SetCaret(offset int, extend bool) {
+	caret := 0
+	for _, stop := range field.stops() {
+		if stop <= offset {
+			caret = stop
+		}
+	}
+	field.caret = caret
+	if !extend {
+		field.anchor = caret
+	}
+	field.scrollToCaret()
+}
+
+// TypeText inserts text at the caret, replacing the selection. Line breaks become spaces and other
+// control characters are dropped, and the text is cut short if the field would get too long.
+func (field *TextField) TypeText(text string) {
+	start, end := field.GetSelection()
+	inserted := field.limit(singleLine(text), utf8.RuneCountInString(field.text[start:end]))
+	field.text = field.text[:start] + inserted + field.text[end:]
+	field.caret = start + len(inserted)
+	field.anchor = field.caret
+	field.scrollToCaret()
+}
+
+// PressKey moves the caret or edits the text in response to a key press, and returns whether the field
+// used the key
+func (field *TextField) PressKey(key Key, mods Modifiers) bool {
+	extend := mods&ModShift != 0
+	start, end := field.GetSelection()
+	switch key {
+	case KeyLeft:
+		if start != end && !extend {
+			field.SetCaret(start, false)
+		} else if mods.IsShortcut() {
+			field.SetCaret(field.previousWord(field.caret), extend)
+		} else {
+			field.SetCaret(field.previousStop(field.caret), extend)
+		}
+	case KeyRight:
+		if start != end && !extend {
+			field.SetCaret(end, false)
+		} else if mods.IsShortcut() {
+			field.SetCaret(field.nextWord(field.caret), extend)
+		} else {
+			field.SetCaret(field.nextStop(field.caret), extend)
+		}
+	case KeyHome:
+		field.SetCaret(0, extend)
+	case KeyEnd:
+		field.SetCaret(len(field.text), extend)
+	case KeyBackspace:
+		if start == end {
+			if mods.IsShortcut() {
+				field.anchor = field.previousWord(field.caret)
+			} else {
+				// backspace removes one rune at a time, so that marks can be taken off of letters
+				_, size := utf8.DecodeLastRuneInString(field.text[:field.caret])
+				field.anchor = field.caret - size
+			}
+		}
+		field.TypeText("")
+	case KeyDelete:
+		if start == end {
+			if mods.IsShortcut() {
+				field.anchor = field.nextWord(field.caret)
+			} else {
+				field.anchor = field.nextStop(field.caret)
+			}
+		}
+		field.TypeText("")
+	case KeyEnter:
+		if field.OnSubmit == nil {
+			return false
+		}
+		field.OnSubmit(field.text)
+	case KeyA:
+		if !mods.IsShortcut() {
+			return false
+		}
+		field.anchor = 0
+		field.SetCaret(len(field.text), true)
+	case KeyC, KeyX:
+		if !mods.IsShortcut() {
+			return false
+		}
+		if field.Clipboard != nil && !field.Password && start != end {
+			field.Clipboard.SetClipboardString(field.text[start:end])
+			if key == KeyX {
+				field.TypeText("")
+			}
+		}
+	case KeyV:
+		if !mods.IsShortcut() {
+			return false
+		}
+		if field.Clipboard != nil {
+			if text, err := field.Clipboard.GetClipboardString(); err == nil {
+				field.TypeText(text)
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// PressMouse moves the caret to the closest place to a point inside the field, selecting the text between
+// it and the old caret when shift is held
+func (field *TextField) PressMouse(point Point, mods Modifiers) {
+	field.SetCaret(field.offsetAt(point), mods&ModShift != 0)
+}
+
+// DragMouse selects the text between where the mouse was pressed and a point inside the field, scrolling
+// the text when the point is past either edge
+func (field *TextField) DragMouse(point Point) {
+	field.SetCaret(field.offsetAt(point), true)
+}
+
+// HandleEvent edits the text and moves the caret in response to input sent to the field
+func (field *TextField) HandleEvent(event *Event) {
+	if event.Phase != PhaseTarget {
+		return
+	}
+	switch event.Type {
+	case MouseDownEvent:
+		if event.Button == MouseLeft {
+			field.PressMouse(event.Point, event.Mods)
+			event.StopPropagation()
+		}
+	case MouseMoveEvent:
+		if event.Buttons&MouseLeft != 0 {
+			field.DragMouse(event.Point)
+			event.StopPropagation()
+		}
+	case KeyDownEvent:
+		if field.PressKey(event.Key, event.Mods) {
+			event.StopPropagation()
+		}
+	case TextInputEvent:
+		field.TypeText(event.Text)
+		event.StopPropagation()
+	}
+}
+
+// SetFocused sets whether the field has keyboard focus
+func (field *TextField) SetFocused(focused bool) {
+	field.focused = focused
+}
+
+// GetBounds determines the bounds of the component
+func (field TextField) GetBounds() Bounds {
+	return field.Bounds
+}
+
+// SetBounds sets the bounds of the component
+func (field *TextField) SetBounds(bounds Bounds) {
+	field.Bounds = bounds
+	field.scrollToCaret()
+}
+
+// GetMinimumSize determines the minimum size of the component, which fits a line of text as wide as
+// Columns digits
+func (field TextField) GetMinimumSize() Bounds {
+	width := float64(field.Columns)*field.Font.GetAdvance("0") + 2*float64(field.Padding)
+	height := field.Font.GetLineHeight() + 2*float64(field.Padding)
+	return NewBounds(0, 0, float32(math.Ceil(width)), float32(math.Ceil(height)))
+}
+
+// Render draws the text, the selection behind it and the caret, cutting off anything scrolled out of view
+func (field TextField) Render(renderer Renderer) {
+	renderer.PushClip(NewBounds(0, 0, field.Bounds.Width, field.Bounds.Height))
+	defer renderer.PopClip()
+
+	shaped := font.ShapeText(field.Font, field.display())
+	lineHeight := float32(field.Font.GetLineHeight())
+	x := field.Padding - float32(field.scroll)
+	top := float32(math.Round(float64(field.Bounds.Height-lineHeight) / 2))
+
+	// right to left text can split the selection into several pieces, so fill behind each selected cluster
+	// on its own, skipping the marks drawn over them
+	start, end := field.GetSelection()
+	start, end = field.toDisplay(start), field.toDisplay(end)
+	clusters := map[int]bool{}
+	for _, caret := range shaped.Carets {
+		clusters[caret.Offset] = true
+	}
+	for _, glyph := range shaped.Glyphs {
+		if glyph.Offset < start || glyph.Offset >= end || !clusters[glyph.Offset] {
+			continue
+		}
+		left := math.Round(float64(x) + glyph.X)
+		right := math.Round(float64(x) + glyph.X + glyph.Font.GetGlyph(glyph.Index).AdvanceWidth)
+		renderer.FillRect(NewBounds(float32(left), top, float32(right-left), lineHeight), field.SelectionColor)
+	}
+	renderer.DrawGlyphs(field.Font, shaped.Glyphs, x, top+float32(field.Font.GetAscent()), field.Color)
+	if field.focused {
+		caretX := float32(math.Round(float64(x) + shaped.GetCaretX(field.toDisplay(field.caret))))
+		renderer.FillRect(NewBounds(caretX, top, 1, lineHeight), field.Color)
+	}
+}
+
+// display gets the text as it is drawn, which hides every rune of a password
+func (field TextField) display() string {
+	if field.Password {
+		return strings.Repeat(string(passwordMask), utf8.RuneCountInString(field.text))
+	}
+	return field.text
+}
+
+// toDisplay converts a byte offset in the text to the matching byte offset in the displayed text
+func (field TextField) toDisplay(offset int) int {
+	if field.Password {
+		return utf8.RuneCountInString(field.text[:offset]) * utf8.RuneLen(passwordMask)
+	}
+	return offset
+}
+
+// fromDisplay converts a byte offset in the displayed text to the matching byte offset in the text
+func (field TextField) fromDisplay(offset int) int {
+	if !field.Password {
+		return offset
+	}
+	runes := offset / utf8.RuneLen(passwordMask)
+	for i := range field.text {
+		if runes == 0 {
+			return i
+		}
+		runes--
+	}
+	return len(field.text)
+}
+
+// stops gets the byte offsets the caret can stop at in order, which skip over combining marks and the
+// middle of anything else that is drawn as a single glyph
+func (field TextField) stops() []int {
+	var stops []int
+	for _, caret := range font.ShapeText(field.Font, field.display()).Carets {
+		stops = append(stops, field.fromDisplay(caret.Offset))
+	}
+	return stops
+}
+
+// previousStop gets the last place the caret can stop before an offset
+func (field TextField) previousStop(offset int) int {
+	previous := 0
+	for _, stop := range field.stops() {
+		if stop < offset {
+			previous = stop
+		}
+	}
+	return previous
+}
+
+// nextStop gets the first place the caret can stop after an offset
+func (field TextField) nextStop(offset int) int {
+	for _, stop := range field.stops() {
+		if stop > offset {
+			return stop
+		}
+	}
+	return len(field.text)
+}
+
+// previousWord gets the start of the word before an offset. Passwords are treated as a single word so
+// that the caret doesn't give away where their spaces are.
+func (field TextField) previousWord(offset int) int {
+	if field.Password {
+		return 0
+	}
+	return previousWord(field.text, offset)
+}
+
+// nextWord gets the end of the word after an offset, along with the spaces after it
+func (field TextField) nextWord(offset int) int {
+	if field.Password {
+		return len(field.text)
+	}
+	return nextWord(field.text, offset)
+}
+
+// offsetAt gets the byte offset of the caret closest to a point inside the field
+func (field TextField) offsetAt(point Point) int {
+	shaped := font.ShapeText(field.Font, field.display())
+	return field.fromDisplay(shaped.GetOffsetAt(float64(point.X-field.Padding) + field.scroll))
+}
+
+// scrollToCaret scrolls the text as little as possible to bring the caret into view, without leaving
+// space after the end of the text that could be filled by scrolling back
+func (field *TextField) scrollToCaret() {
+	if field.Font == nil {
+		return
+	}
+	width := float64(field.Bounds.Width - 2*field.Padding)
+	if width <= 0 {
+		field.scroll = 0
+		return
+	}
+	shaped := font.ShapeText(field.Font, field.display())
+	caretX := shaped.GetCaretX(field.toDisplay(field.caret))
+	if caretX-field.scroll > width {
+		field.scroll = caretX - width
+	}
+	if caretX < field.scroll {
+		field.scroll = caretX
+	}
+	field.scroll = math.Max(0, math.Min(field.scroll, shaped.Advance-width))
+}
+
+// limit cuts text short so that it fits in the field after replacing removed runes of the current text
+func (field TextField) limit(text string, removed int) string {
+	if field.MaxLength <= 0 {
+		return text
+	}
+	room := field.MaxLength - utf8.RuneCountInString(field.text) + removed
+	for i := range text {
+		if room <= 0 {
+			return text[:i]
+		}
+		room--
+	}
+	return text
+}
+
+// singleLine turns line breaks into spaces and drops other control characters, so text fits on one line
+func singleLine(text string) string {
+	return strings.Replace(multiLine(text), "\n", " ", -1)
+}
+
+// previousWord gets the byte offset of the start of the word before an offset in a string
+func previousWord(text string, offset int) int {
+	text = strings.TrimRightFunc(text[:offset], unicode.IsSpace)
+	space := strings.LastIndexFunc(text, unicode.IsSpace)
+	if space < 0 {
+		return 0
+	}
+	_, size := utf8.DecodeRuneInString(text[space:])
+	return space + size
+}
+
+// nextWord gets the byte offset of the end of the word after an offset in a string, skipping the spaces
+// after it
+func nextWord(text string, offset int) int {
+	end := strings.IndexFunc(text[offset:], unicode.IsSpace)
+	if end < 0 {
+		return len(text)
+	}
+	return len(text) - len(strings.TrimLeftFunc(text[offset+end:], unicode.IsSpace))
+}
@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"testing"
+)
+
+type testClipboard struct {
+	text string
+}
+
+func (clipboard testClipboard) GetClipboardString() (string, error) {
+	return clipboard.text, nil
+}
+
+func (clipboard *testClipboard) SetClipboardString(str string) {
+	clipboard.text = str
+}
+
+func CheckField(t *testing.T, field *TextField, text string, start int, end int) {
+	t.Helper()
+	if field.GetText() != text {
+		t.Errorf("Invalid text: expected %q, got %q", text, field.GetText())
+	}
+	if s, e := field.GetSelection(); s != start || e != end {
+		t.Errorf("Invalid selection: expected %d to %d, got %d to %d", start, end, s, e)
+	}
+}
+
+func TestTextFieldEditing(t *testing.T) {
+	field := NewTextField(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	field.TypeText("Hello world")
+	CheckField(t, &field, "Hello world", 11, 11)
+	for i := 0; i < 5; i++ {
+		field.PressKey(KeyLeft, ModShift)
+	}
+	CheckField(t, &field, "Hello world", 6, 11)
+	field.TypeText("Mars\n")
+	CheckField(t, &field, "Hello Mars ", 11, 11)
+	field.PressKey(KeyBackspace, 0)
+	field.PressKey(KeyLeft, ModControl)
+	CheckField(t, &field, "Hello Mars", 6, 6)
+	field.PressKey(KeyBackspace, ModControl)
+	CheckField(t, &field, "Mars", 0, 0)
+	field.PressKey(KeyRight, ModControl|ModShift)
+	CheckField(t, &field, "Mars", 0, 4)
+	field.PressKey(KeyHome, 0)
+	field.PressKey(KeyDelete, 0)
+	CheckField(t, &field, "ars", 0, 0)
+	field.PressKey(KeyEnd, ModShift)
+	CheckField(t, &field, "ars", 0, 3)
+	if field.PressKey(KeyA, 0) || field.PressKey(KeyUnknown, 0) {
+		t.Error("Expected the field to ignore keys it doesn't use")
+	}
+
+	var submitted string
+	field.OnSubmit = func(text string) {
+		submitted = text
+	}
+	if !field.PressKey(KeyEnter, 0) || submitted != "ars" {
+		t.Error("Expected enter to submit the text")
+	}
+}
+
+func TestTextFieldCombiningMarks(t *testing.T) {
+	field := NewTextField(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	field.SetText("e\u0301x")
+	field.PressKey(KeyHome, 0)
+	field.PressKey(KeyRight, 0)
+	CheckField(t, &field, "e\u0301x", 3, 3)
+	field.SetCaret(2, false)
+	CheckField(t, &field, "e\u0301x", 0, 0)
+	// backspace takes the mark off of the letter, while delete removes both
+	field.SetCaret(3, false)
+	field.PressKey(KeyBackspace, 0)
+	CheckField(t, &field, "ex", 1, 1)
+	field.SetText("e\u0301x")
+	field.PressKey(KeyHome, 0)
+	field.PressKey(KeyDelete, 0)
+	CheckField(t, &field, "x", 0, 0)
+}
+
+func TestTextFieldMaxLength(t *testing.T) {
+	field := NewTextField(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	field.MaxLength = 5
+	field.SetText("\u00e9tudiant")
+	CheckField(t, &field, "\u00e9tudi", 6, 6)
+	field.TypeText("x")
+	CheckField(t, &field, "\u00e9tudi", 6, 6)
+	field.PressKey(KeyLeft, ModShift)
+	field.PressKey(KeyLeft, ModShift)
+	field.TypeText("xyz")
+	CheckField(t, &field, "\u00e9tuxy", 6, 6)
+}
+
+func TestTextFieldClipboard(t *testing.T) {
+	clipboard := &testClipboard{}
+	field := NewTextField(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	field.Clipboard = clipboard
+	field.SetText("Olympus Mons")
+	field.PressKey(KeyA, ModControl)
+	field.PressKey(KeyC, ModSuper)
+	if clipboard.text != "Olympus Mons" {
+		t.Errorf("Expected the text to be copied, got %q", clipboard.text)
+	}
+	field.PressKey(KeyEnd, 0)
+	field.PressKey(KeyLeft, ModShift|ModControl)
+	field.PressKey(KeyX, ModControl)
+	CheckField(t, &field, "Olympus ", 8, 8)
+	clipboard.text = "a\r\nb\tc\x00"
+	field.PressKey(KeyV, ModControl)
+	CheckField(t, &field, "Olympus a b c", 13, 13)
+
+	field.Password = true
+	clipboard.text = ""
+	field.PressKey(KeyA, ModControl)
+	field.PressKey(KeyC, ModControl)
+	if clipboard.text != "" {
+		t.Error("Expected passwords not to be copied")
+	}
+}
+
+func TestTextFieldMouse(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	field := NewTextField(fnt, [4]float32{1, 1, 1, 1})
+	field.SetBounds(NewBounds(0, 0, 200, 24))
+	field.SetText("Phobos Deimos")
+	field.PressMouse(NewPoint(field.Padding+float32(fnt.GetAdvance("Pho"))+1, 10), 0)
+	CheckField(t, &field, "Phobos Deimos", 3, 3)
+	field.PressMouse(NewPoint(field.Padding+float32(fnt.GetAdvance("Phobos D")), 10), ModShift)
+	CheckField(t, &field, "Phobos Deimos", 3, 8)
+	field.DragMouse(NewPoint(-20, 10))
+	CheckField(t, &field, "Phobos Deimos", 0, 3)
+}
+
+func TestTextFieldScroll(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	field := NewTextField(fnt, [4]float32{1, 1, 1, 1})
+	field.SetBounds(NewBounds(0, 0, 60, 24))
+	field.SetText("Valles Marineris")
+	width := float64(60 - 2*field.Padding)
+	if expected := fnt.GetAdvance("Valles Marineris") - width; field.scroll != expected {
+		t.Errorf("Expected the end of the text to be in view, got %f instead of %f", field.scroll, expected)
+	}
+	// clicking in the field finds the caret in the scrolled text
+	field.PressMouse(NewPoint(field.Padding, 10), 0)
+	if start, _ := field.GetSelection(); fnt.GetAdvance(field.GetText()[:start]) < field.scroll-5 {
+		t.Errorf("Expected the caret to be placed in the scrolled text, got %d", start)
+	}
+	field.PressKey(KeyHome, 0)
+	if field.scroll != 0 {
+		t.Errorf("Expected the start of the text to be in view, got %f", field.scroll)
+	}
+	field.SetText("Short")
+	if field.scroll != 0 {
+		t.Errorf("Expected short text not to scroll, got %f", field.scroll)
+	}
+}
+
+func TestTextFieldPassword(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	field := NewTextField(fnt, [4]float32{1, 1, 1, 1})
+	field.Password = true
+	field.SetText("p\u00e4ss word")
+	if field.display() != "\u2022\u2022\u2022\u2022\u2022\u2022\u2022\u2022\u2022" {
+		t.Errorf("Expected every rune to be hidden, got %q", field.display())
+	}
+	field.PressKey(KeyLeft, ModControl)
+	CheckField(t, &field, "p\u00e4ss word", 0, 0)
+	field.PressKey(KeyRight, 0)
+	field.PressKey(KeyRight, 0)
+	CheckField(t, &field, "p\u00e4ss word", 3, 3)
+}
+
+func TestGoldenTextField(t *testing.T) {
+	field := NewTextField(LoadTestFont(t, 16), [4]float32{1, 1, 1, 1})
+	field.SetBounds(NewBounds(0, 0, 100, 24))
+	field.SetFocused(true)
+	field.SetText("Decimate the red planet")
+	field.PressKey(KeyLeft, ModControl)
+	field.PressKey(KeyLeft, ModControl|ModShift)
+	CheckGolden(t, "text_field", &field, 100, 24, 8)
+}
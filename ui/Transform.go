@@ -0,0 +1,70 @@
+package ui
+
+// Transform represents a 2D affine transform as the top two rows of a 3x3 matrix, so a point
+// (x, y) maps to (t[0]*x + t[1]*y + t[2], t[3]*x + t[4]*y + t[5])
+type Transform [6]float32
+
+// IdentityTransform creates a transform that leaves points where they are
+func IdentityTransform() Transform {
+	return Transform{1, 0, 0, 0, 1, 0}
+}
+
+// TranslateTransform creates a transform that moves points by the given offset
+func TranslateTransform(x float32, y float32) Transform {
+	return Transform{1, 0, x, 0, 1, y}
+}
+
+// ScaleTransform creates a transform that scales points away from the origin
+func ScaleTransform(x float32, y float32) Transform {
+	return Transform{x, 0, 0, 0, y, 0}
+}
+
+// Multiply combines two transforms so that other is applied first and then transform
+func (transform Transform) Multiply(other Transform) Transform {
+	return Transform{
+		transform[0]*other[0] + transform[1]*other[3],
+		transform[0]*other[1] + transform[1]*other[4],
+		transform[0]*other[2] + transform[1]*other[5] + transform[2],
+		transform[3]*other[0] + transform[4]*other[3],
+		transform[3]*other[1] + transform[4]*other[4],
+		transform[3]*other[2] + transform[4]*other[5] + transform[5],
+	}
+}
+
+// Apply maps a point through the transform
+func (transform Transform) Apply(point Point) Point {
+	return Point{
+		X: transform[0]*point.X + transform[1]*point.Y + transform[2],
+		Y: transform[3]*point.X + transform[4]*point.Y + transform[5],
+	}
+}
+
+// Invert creates the transform that undoes this one. It fails for transforms that squash everything
+// onto a line or a point, since they can't be undone.
+func (transform Transform) Invert() (Transform, bool) {
+	det := transform[0]*transform[4] - transform[1]*transform[3]
+	if det == 0 {
+		return Transform{}, false
+	}
+	a, b := transform[4]/det, -transform[1]/det
+	c, d := -transform[3]/det, transform[0]/det
+	return Transform{
+		a, b, -(a*transform[2] + b*transform[5]),
+		c, d, -(c*transform[2] + d*transform[5]),
+	}, true
+}
+
+// IsTranslation determines whether the transform only moves points without scaling, rotating or skewing them
+func (transform Transform) IsTranslation() bool {
+	return transform[0] == 1 && transform[1] == 0 && transform[3] == 0 && transform[4] == 1
+}
+
+// Matrix converts the transform to a column-major 4x4 matrix suitable for OpenGL
+func (transform Transform) Matrix() [16]float32 {
+	return [16]float32{
+		transform[0], transform[3], 0, 0,
+		transform[1], transform[4], 0, 0,
+		0, 0, 1, 0,
+		transform[2], transform[5], 0, 1,
+	}
+}
@@ -0,0 +1,311 @@
+package font
+
+import (
+	"image"
+	"math"
+
+	"github.com/golang/freetype/raster"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultAtlasPageSize is the width and height in pixels of the atlas pages created by LoadFont
+	DefaultAtlasPageSize = 512
+	// DefaultAtlasMaxPages is the number of atlas pages LoadFont allows before glyphs start getting evicted
+	DefaultAtlasMaxPages = 4
+	// atlasPadding is the number of empty pixels left around each glyph so they don't bleed into each other
+	atlasPadding = 1
+)
+
+// Atlas caches rasterized glyphs on one or more texture pages. When every page is full, the page
+// that was least recently drawn from is cleared and reused.
+type Atlas struct {
+	PageSize int
+	MaxPages int
+	// Spread is how far either side of the glyph outlines the distance fields stored in the atlas reach,
+	// or 0 when the atlas stores anti-aliased glyphs
+	Spread  int
+	Pages   []*AtlasPage
+	entries map[atlasKey]*AtlasEntry
+	clock   uint64
+}
+
+// atlasKey identifies a glyph rasterized at one of the subpixel offsets of its font
+type atlasKey struct {
+	index    truetype.Index
+	subpixel int
+}
+
+// AtlasPage is a single texture of rasterized glyphs
+type AtlasPage struct {
+	Image *image.Alpha
+	// Version changes whenever the pixels of the page change, so renderers know when to upload it again
+	Version  int
+	shelves  []atlasShelf
+	lastUsed uint64
+	entries  []atlasKey
+}
+
+// atlasShelf is a row of glyphs on a page that all fit within its height
+type atlasShelf struct {
+	y      int
+	height int
+	x      int
+}
+
+// AtlasEntry describes where a rasterized glyph is on its page
+type AtlasEntry struct {
+	Page *AtlasPage
+	// Bounds is the region of the page containing the glyph
+	Bounds image.Rectangle
+	// Left and Top are the offset of the top left corner of the glyph's image from its origin on the baseline
+	Left int
+	Top  int
+}
+
+// GlyphQuad is a rectangle on the screen to fill with a region of an atlas page
+type GlyphQuad struct {
+	Page   *AtlasPage
+	Source image.Rectangle
+	X      float64
+	Y      float64
+	// Spread is the spread of the distance field on the page, or 0 when the page holds anti-aliased glyphs
+	Spread int
+}
+
+// NewAtlas creates an empty atlas with pages of the given size
+func NewAtlas(pageSize int, maxPages int) *Atlas {
+	return &Atlas{
+		PageSize: pageSize,
+		MaxPages: maxPages,
+		Pages:    []*AtlasPage{},
+		entries:  map[atlasKey]*AtlasEntry{},
+	}
+}
+
+// Clear throws away every glyph in the atlas, keeping its pages so they can be filled again
+func (atlas *Atlas) Clear() {
+	for _, page := range atlas.Pages {
+		page.clear()
+	}
+	atlas.entries = map[atlasKey]*AtlasEntry{}
+}
+
+// DrawString lays out a string with its baseline starting at x, y as a batch of quads that each copy one
+// glyph from the font's atlas, rasterizing any glyphs that aren't in the atlas yet
+func DrawString(font *LoadedFont, str string, x, y float64) []GlyphQuad {
+	glyphs, _ := PositionGlyphs(font, str)
+	return DrawGlyphs(font, glyphs, x, y)
+}
+
+// DrawGlyphs turns glyphs positioned relative to x, y into a batch of quads that each copy one glyph
+// from the font's atlas, rasterizing any glyphs that aren't in the atlas yet
+func DrawGlyphs(font *LoadedFont, glyphs []PositionedGlyph, x, y float64) []GlyphQuad {
+	var quads []GlyphQuad
+	drawing := map[*LoadedFont]bool{}
+	for _, positioned := range glyphs {
+		fnt := positioned.getFont(font)
+		if !drawing[fnt] {
+			// glyphs already drawn from this font's atlas during this call can't be evicted
+			fnt.Atlas.clock++
+			drawing[fnt] = true
+		}
+		originX, originY := x+positioned.X, y+positioned.Y
+		subpixel := 0
+		if !fnt.IsDistanceField() {
+			// anti-aliased glyphs only look sharp when their pixels line up with the screen, so glyphs that
+			// can be placed between pixels are rasterized at the offset closest to where they are drawn
+			positions := math.Max(1, float64(fnt.Options.SubpixelPositions))
+			offset := math.Floor(originX*positions + 0.5)
+			originX = math.Floor(offset / positions)
+			subpixel = int(offset - originX*positions)
+			originY = math.Floor(originY + 0.5)
+		}
+		entry := fnt.Atlas.GetEntry(positioned.Index, subpixel, fnt)
+		if entry == nil {
+			continue
+		}
+		quads = append(quads, GlyphQuad{
+			Page:   entry.Page,
+			Source: entry.Bounds,
+			X:      originX + float64(entry.Left),
+			Y:      originY - float64(entry.Top),
+			Spread: fnt.Atlas.Spread,
+		})
+	}
+	return quads
+}
+
+// GetEntry finds a glyph from a font in the atlas, rasterizing it onto a page first if it isn't there.
+// Subpixel is which of the font's subpixel positions the glyph is shifted right by. It returns nil for
+// glyphs with nothing to draw and for glyphs that can't fit on any page.
+func (atlas *Atlas) GetEntry(index truetype.Index, subpixel int, font *LoadedFont) *AtlasEntry {
+	key := atlasKey{index, subpixel}
+	if entry, ok := atlas.entries[key]; ok {
+		if entry != nil {
+			entry.Page.lastUsed = atlas.clock
+		}
+		return entry
+	}
+
+	var mask *image.Alpha
+	var left, top int
+	contours := font.GetGlyph(index).GetContours()
+	if atlas.Spread > 0 {
+		mask, left, top = generateDistanceField(contours, atlas.Spread)
+	} else {
+		offset := 0.0
+		if font.Options.SubpixelPositions > 1 {
+			offset = float64(subpixel) / float64(font.Options.SubpixelPositions)
+		}
+		mask, left, top = rasterizeGlyph(contours, offset)
+	}
+	// remember glyphs that will never be on a page, so they aren't rasterized again every time they're drawn
+	if mask == nil || !atlas.fits(mask.Rect.Dx(), mask.Rect.Dy()) {
+		atlas.entries[key] = nil
+		return nil
+	}
+	page, bounds, ok := atlas.allocate(mask.Rect.Dx(), mask.Rect.Dy())
+	if !ok {
+		return nil
+	}
+	blitGlyph(page.Image, bounds, mask)
+	page.Version++
+	page.lastUsed = atlas.clock
+	page.entries = append(page.entries, key)
+	entry := &AtlasEntry{
+		Page:   page,
+		Bounds: bounds,
+		Left:   left,
+		Top:    top,
+	}
+	atlas.entries[key] = entry
+	return entry
+}
+
+// fits determines whether a glyph of the given size is small enough to go on a page
+func (atlas *Atlas) fits(width int, height int) bool {
+	return width+atlasPadding <= atlas.PageSize && height+atlasPadding <= atlas.PageSize
+}
+
+// allocate finds room for a glyph of the given size, adding or evicting pages as needed
+func (atlas *Atlas) allocate(width int, height int) (*AtlasPage, image.Rectangle, bool) {
+	if !atlas.fits(width, height) {
+		return nil, image.Rectangle{}, false
+	}
+	for _, page := range atlas.Pages {
+		if bounds, ok := page.allocate(width, height, atlas.PageSize); ok {
+			return page, bounds, true
+		}
+	}
+	if len(atlas.Pages) < atlas.MaxPages {
+		page := &AtlasPage{
+			Image: image.NewAlpha(image.Rect(0, 0, atlas.PageSize, atlas.PageSize)),
+		}
+		atlas.Pages = append(atlas.Pages, page)
+		bounds, ok := page.allocate(width, height, atlas.PageSize)
+		return page, bounds, ok
+	}
+
+	// evict the least recently used page, unless it's being used by the string currently being drawn
+	var oldest *AtlasPage
+	for _, page := range atlas.Pages {
+		if page.lastUsed < atlas.clock && (oldest == nil || page.lastUsed < oldest.lastUsed) {
+			oldest = page
+		}
+	}
+	if oldest == nil {
+		return nil, image.Rectangle{}, false
+	}
+	for _, key := range oldest.entries {
+		delete(atlas.entries, key)
+	}
+	oldest.clear()
+	bounds, ok := oldest.allocate(width, height, atlas.PageSize)
+	return oldest, bounds, ok
+}
+
+// clear erases every glyph from the page
+func (page *AtlasPage) clear() {
+	page.entries = nil
+	page.shelves = nil
+	for i := range page.Image.Pix {
+		page.Image.Pix[i] = 0
+	}
+	page.Version++
+}
+
+// allocate finds room for a glyph on the page by packing glyphs into shelves
+func (page *AtlasPage) allocate(width int, height int, pageSize int) (image.Rectangle, bool) {
+	width += atlasPadding
+	height += atlasPadding
+	bottom := 0
+	for i := range page.shelves {
+		shelf := &page.shelves[i]
+		bottom = shelf.y + shelf.height
+		// don't waste a tall shelf on a much shorter glyph
+		if height <= shelf.height && height*2 > shelf.height && shelf.x+width <= pageSize {
+			bounds := image.Rect(shelf.x, shelf.y, shelf.x+width-atlasPadding, shelf.y+height-atlasPadding)
+			shelf.x += width
+			return bounds, true
+		}
+	}
+	if bottom+height > pageSize || width > pageSize {
+		return image.Rectangle{}, false
+	}
+	page.shelves = append(page.shelves, atlasShelf{y: bottom, height: height, x: width})
+	return image.Rect(0, bottom, width-atlasPadding, bottom+height-atlasPadding), true
+}
+
+// rasterizeGlyph draws the anti-aliased outline of a glyph shifted right by offset pixels into a new image
+// and returns it along with the offset of its top left corner from the glyph's origin
+func rasterizeGlyph(contours [][]Point, offset float64) (*image.Alpha, int, int) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, contour := range contours {
+		for _, point := range contour {
+			point.X += offset
+			minX = math.Min(minX, point.X)
+			minY = math.Min(minY, point.Y)
+			maxX = math.Max(maxX, point.X)
+			maxY = math.Max(maxY, point.Y)
+		}
+	}
+	if minX >= maxX || minY >= maxY {
+		return nil, 0, 0
+	}
+	left := int(math.Floor(minX))
+	top := int(math.Ceil(maxY))
+	width := int(math.Ceil(maxX)) - left
+	height := top - int(math.Floor(minY))
+
+	rasterizer := raster.NewRasterizer(width, height)
+	rasterizer.UseNonZeroWinding = true
+	toPoint := func(point Point) fixed.Point26_6 {
+		return fixed.Point26_6{
+			X: toFixed(point.X + offset - float64(left)),
+			Y: toFixed(float64(top) - point.Y),
+		}
+	}
+	for _, contour := range contours {
+		if len(contour) == 0 {
+			continue
+		}
+		rasterizer.Start(toPoint(contour[0]))
+		for _, point := range contour[1:] {
+			rasterizer.Add1(toPoint(point))
+		}
+		rasterizer.Add1(toPoint(contour[0]))
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	rasterizer.Rasterize(raster.NewAlphaSrcPainter(mask))
+	return mask, left, top
+}
+
+// blitGlyph copies a glyph image into a region of a page
+func blitGlyph(dst *image.Alpha, bounds image.Rectangle, src *image.Alpha) {
+	for y := 0; y < bounds.Dy(); y++ {
+		copy(dst.Pix[dst.PixOffset(bounds.Min.X, bounds.Min.Y+y):], src.Pix[src.PixOffset(0, y):src.PixOffset(0, y)+bounds.Dx()])
+	}
+}
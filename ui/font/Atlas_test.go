@@ -0,0 +1,85 @@
+package font
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAtlasReuse(t *testing.T) {
+	fnt := LoadTestFont(t, 24)
+	quads := DrawString(fnt, "a a", 0, 0)
+	if len(quads) != 2 {
+		t.Fatalf("Expected 2 quads, got %d", len(quads))
+	}
+	if quads[0].Page != quads[1].Page || quads[0].Source != quads[1].Source {
+		t.Error("Expected both glyphs to share an atlas entry")
+	}
+	if quads[1].X <= quads[0].X {
+		t.Error("Expected the second glyph to be to the right of the first")
+	}
+	version := quads[0].Page.Version
+	DrawString(fnt, "aaa", 0, 0)
+	if quads[0].Page.Version != version {
+		t.Error("Expected the atlas page not to change when drawing cached glyphs")
+	}
+}
+
+func TestAtlasEviction(t *testing.T) {
+	fnt := LoadTestFont(t, 24)
+	fnt.Atlas = NewAtlas(48, 2)
+	DrawString(fnt, "ABCD", 0, 0)
+	for _, str := range []string{"EFGH", "IJKL", "MNOP", "QRST"} {
+		DrawString(fnt, str, 0, 0)
+	}
+	if len(fnt.Atlas.Pages) != 2 {
+		t.Errorf("Expected 2 pages, got %d", len(fnt.Atlas.Pages))
+	}
+	if _, ok := fnt.Atlas.entries[atlasKey{fnt.Font.Index('A'), 0}]; ok {
+		t.Error("Expected the least recently used glyphs to be evicted")
+	}
+	if again := DrawString(fnt, "A", 0, 0); len(again) != 1 {
+		t.Errorf("Expected evicted glyphs to be drawn again, got %d quads", len(again))
+	}
+
+	// glyphs bigger than a page are remembered as not being drawable from the atlas
+	fnt.Atlas = NewAtlas(8, 2)
+	if quads := DrawString(fnt, "W", 0, 0); len(quads) != 0 {
+		t.Errorf("Expected no quads for a glyph bigger than a page, got %d", len(quads))
+	}
+	if entry, ok := fnt.Atlas.entries[atlasKey{fnt.Font.Index('W'), 0}]; !ok || entry != nil {
+		t.Error("Expected the glyph to be remembered as too big for the atlas")
+	}
+}
+
+func TestSubpixelPositions(t *testing.T) {
+	fnt, err := LoadFontWithOptions("fonts/Go-Regular.ttf", 16, FontOptions{SubpixelPositions: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := DrawString(fnt, "l", 10, 0)[0]
+	sources := map[image.Rectangle]bool{first.Source: true}
+	for _, x := range []float64{10.25, 10.5, 10.75} {
+		quad := DrawString(fnt, "l", x, 0)[0]
+		if quad.X != first.X {
+			t.Errorf("Expected the glyph drawn at %f to start on the same pixel, got %f and %f", x, quad.X, first.X)
+		}
+		if sources[quad.Source] {
+			t.Errorf("Expected the glyph drawn at %f to use its own rasterization", x)
+		}
+		sources[quad.Source] = true
+	}
+	// positions closer to the next pixel are drawn from there without any offset
+	if next := DrawString(fnt, "l", 10.9, 0)[0]; next.Source != first.Source || next.X != first.X+1 {
+		t.Errorf("Expected the glyph drawn at 10.9 to be the unshifted glyph one pixel along, got %v", next)
+	}
+	if len(fnt.Atlas.entries) != 4 {
+		t.Errorf("Expected 4 rasterizations of the glyph, got %d", len(fnt.Atlas.entries))
+	}
+
+	whole := LoadTestFont(t, 16)
+	DrawString(whole, "l", 10.25, 0)
+	DrawString(whole, "l", 10.5, 0)
+	if len(whole.Atlas.entries) != 1 {
+		t.Errorf("Expected fonts without subpixel positions to rasterize glyphs once, got %d", len(whole.Atlas.entries))
+	}
+}
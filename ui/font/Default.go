@@ -0,0 +1,33 @@
+package font
+
+import (
+	// embed is needed to include the default fonts in the binary
+	_ "embed"
+)
+
+// DefaultFamily is the name of the font family RegisterDefaults registers the built in fonts under
+const DefaultFamily = "Go"
+
+var (
+	// DefaultRegularFont is the contents of the regular face of the built in font family
+	//go:embed fonts/Go-Regular.ttf
+	DefaultRegularFont []byte
+
+	// DefaultBoldFont is the contents of the bold face of the built in font family
+	//go:embed fonts/Go-Bold.ttf
+	DefaultBoldFont []byte
+)
+
+// RegisterDefaults registers the fonts built in to the binary under DefaultFamily, so there is always
+// something to draw text with no matter what fonts the system has installed
+func (manager *FontManager) RegisterDefaults() error {
+	if err := manager.RegisterBytes(DefaultFamily, Regular, DefaultRegularFont); err != nil {
+		return err
+	}
+	return manager.RegisterBytes(DefaultFamily, Bold, DefaultBoldFont)
+}
+
+// LoadDefaultFont loads the regular face of the built in font family at the specified size in pixels per em
+func LoadDefaultFont(size float64) (*LoadedFont, error) {
+	return LoadFontFromBytes(DefaultRegularFont, size)
+}
@@ -0,0 +1,117 @@
+package font
+
+import (
+	"image"
+	"math"
+)
+
+// DefaultDistanceFieldSpread is the distance in pixels either side of a glyph's outline covered by the
+// distance fields of fonts created with NewDistanceFieldFont, which limits how wide outlines and glows can be
+const DefaultDistanceFieldSpread = 8
+
+// NewDistanceFieldAtlas creates an empty atlas that stores a signed distance field for each glyph instead
+// of its coverage, so the glyphs can be drawn crisply at any scale
+func NewDistanceFieldAtlas(pageSize int, maxPages int, spread int) *Atlas {
+	atlas := NewAtlas(pageSize, maxPages)
+	atlas.Spread = spread
+	return atlas
+}
+
+// NewDistanceFieldFont creates a font at the specified size in pixels per em whose glyphs are drawn from
+// distance fields.
+// Since a distance field can be scaled up and down without blurring, one font can be used for text of
+// every size by scaling it with a transform.
+func NewDistanceFieldFont(fnt *FontFile, size float64, spread int) *LoadedFont {
+	lfont := NewLoadedFont(fnt, size)
+	lfont.Atlas = NewDistanceFieldAtlas(DefaultAtlasPageSize, DefaultAtlasMaxPages, spread)
+	return lfont
+}
+
+// IsDistanceField determines whether the font's glyphs are drawn from distance fields
+func (font *LoadedFont) IsDistanceField() bool {
+	return font.Atlas.Spread > 0
+}
+
+// Distance decodes a value sampled from the quad's distance field, between 0 and 1, into how many pixels
+// inside the glyph outline it is
+func (quad GlyphQuad) Distance(value float64) float64 {
+	return (value - 0.5) * 2 * float64(quad.Spread)
+}
+
+// generateDistanceField calculates how far the center of each pixel around a glyph is from its outline,
+// mapping the edge to half intensity and spread pixels outside or inside to zero or full intensity. It
+// returns the image along with the offset of its top left corner from the glyph's origin.
+func generateDistanceField(contours [][]Point, spread int) (*image.Alpha, int, int) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, contour := range contours {
+		for _, point := range contour {
+			minX = math.Min(minX, point.X)
+			minY = math.Min(minY, point.Y)
+			maxX = math.Max(maxX, point.X)
+			maxY = math.Max(maxY, point.Y)
+		}
+	}
+	if minX >= maxX || minY >= maxY {
+		return nil, 0, 0
+	}
+	left := int(math.Floor(minX)) - spread
+	top := int(math.Ceil(maxY)) + spread
+	width := int(math.Ceil(maxX)) + spread - left
+	height := top - (int(math.Floor(minY)) - spread)
+
+	field := image.NewAlpha(image.Rect(0, 0, width, height))
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			point := Point{X: float64(left+px) + 0.5, Y: float64(top-py) - 0.5}
+			distance := math.Sqrt(distanceToContours(contours, point))
+			if windingNumber(contours, point) == 0 {
+				distance = -distance
+			}
+			value := 0.5 + distance/float64(2*spread)
+			field.Pix[field.PixOffset(px, py)] = uint8(math.Max(0, math.Min(1, value))*255 + 0.5)
+		}
+	}
+	return field, left, top
+}
+
+// distanceToContours finds the squared distance from a point to the closest edge of the contours
+func distanceToContours(contours [][]Point, point Point) float64 {
+	closest := math.Inf(1)
+	for _, contour := range contours {
+		for i := range contour {
+			a, b := contour[i], contour[(i+1)%len(contour)]
+			dx, dy := b.X-a.X, b.Y-a.Y
+			t := 0.0
+			if length := dx*dx + dy*dy; length > 0 {
+				t = math.Max(0, math.Min(1, ((point.X-a.X)*dx+(point.Y-a.Y)*dy)/length))
+			}
+			ex, ey := a.X+t*dx-point.X, a.Y+t*dy-point.Y
+			closest = math.Min(closest, ex*ex+ey*ey)
+		}
+	}
+	return closest
+}
+
+// windingNumber counts how many times the contours wind around a point, so that it is inside the glyph
+// when the count isn't zero
+func windingNumber(contours [][]Point, point Point) int {
+	winding := 0
+	for _, contour := range contours {
+		for i := range contour {
+			a, b := contour[i], contour[(i+1)%len(contour)]
+			if (a.Y <= point.Y) == (b.Y <= point.Y) {
+				continue
+			}
+			x := a.X + (point.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if x > point.X {
+				if b.Y > a.Y {
+					winding++
+				} else {
+					winding--
+				}
+			}
+		}
+	}
+	return winding
+}
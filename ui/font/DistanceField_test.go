@@ -0,0 +1,51 @@
+package font
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceField(t *testing.T) {
+	// a 20 pixel square with a 10 pixel hole in the middle
+	contours := [][]Point{Square(0, 0, 20, false), Square(5, 5, 10, true)}
+	field, left, top := generateDistanceField(contours, 4)
+	if left != -4 || top != 24 || field.Rect.Dx() != 28 || field.Rect.Dy() != 28 {
+		t.Fatalf("Invalid distance field bounds: %d, %d, %v", left, top, field.Rect)
+	}
+	value := func(x, y float64) float64 {
+		return float64(field.Pix[field.PixOffset(int(x)-left, top-int(y)-1)]) / 255
+	}
+	quad := GlyphQuad{Spread: 4}
+	for _, test := range []struct {
+		x, y     float64
+		distance float64
+	}{
+		{-4, 10, -3.5},
+		{0, 10, 0.5},
+		{2, 10, 2.5},
+		{4, 10, 0.5},
+		{7, 10, -2.5},
+		{10, 10, -4},
+		{19, 19, 0.5},
+	} {
+		if distance := quad.Distance(value(test.x, test.y)); math.Abs(distance-test.distance) > 0.05 {
+			t.Errorf("Invalid distance at (%.0f, %.0f): expected %.1f, got %.2f", test.x, test.y, test.distance, distance)
+		}
+	}
+}
+
+func TestDistanceFieldFont(t *testing.T) {
+	regular := LoadTestFont(t, 24)
+	fnt := NewDistanceFieldFont(regular.Font, 24, DefaultDistanceFieldSpread)
+	if regular.IsDistanceField() || !fnt.IsDistanceField() {
+		t.Error("Expected only the distance field font to use distance fields")
+	}
+	quads := DrawString(fnt, "o", 0.25, 0)
+	if len(quads) != 1 || quads[0].Spread != DefaultDistanceFieldSpread {
+		t.Fatal("Expected a quad from the distance field atlas")
+	}
+	bounds := regular.GetInkBounds("o")
+	if quads[0].X != 0.25+math.Floor(bounds.X)-DefaultDistanceFieldSpread {
+		t.Errorf("Expected the quad to surround the glyph without being rounded, got %f", quads[0].X)
+	}
+}
@@ -0,0 +1,57 @@
+package font
+
+import (
+	"math"
+)
+
+const (
+	// DefaultTolerance is how far in pixels the straight lines making up a glyph's outline may stray from
+	// its curves in fonts created by LoadFont
+	DefaultTolerance = 0.05
+	// maxFlattenDepth limits how many times a curve is split in half, in case the tolerance can never be met
+	maxFlattenDepth = 16
+)
+
+// FlattenQuadratic approximates the quadratic bézier curve from p0 to p2 with straight lines that stray
+// no further than tolerance from it, appending the end of each line to points. Curves are split in half
+// until each piece is flat enough, so gentle curves only need a few lines while sharp ones get more.
+func FlattenQuadratic(points []Point, p0, p1, p2 Point, tolerance float64) []Point {
+	return flattenQuadratic(points, p0, p1, p2, tolerance, 0)
+}
+
+// FlattenCubic approximates the cubic bézier curve from p0 to p3 with straight lines that stray no
+// further than tolerance from it, appending the end of each line to points
+func FlattenCubic(points []Point, p0, p1, p2, p3 Point, tolerance float64) []Point {
+	return flattenCubic(points, p0, p1, p2, p3, tolerance, 0)
+}
+
+// flattenQuadratic flattens one piece of a quadratic curve that has already been split depth times
+func flattenQuadratic(points []Point, p0, p1, p2 Point, tolerance float64, depth int) []Point {
+	// the curve strays from its chord by at most a quarter of how far the control point bends it
+	if depth >= maxFlattenDepth || math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y)/4 <= tolerance {
+		return append(points, p2)
+	}
+	a, b := midpoint(p0, p1), midpoint(p1, p2)
+	m := midpoint(a, b)
+	points = flattenQuadratic(points, p0, a, m, tolerance, depth+1)
+	return flattenQuadratic(points, m, b, p2, tolerance, depth+1)
+}
+
+// flattenCubic flattens one piece of a cubic curve that has already been split depth times
+func flattenCubic(points []Point, p0, p1, p2, p3 Point, tolerance float64, depth int) []Point {
+	// the curve strays from its chord by at most three quarters of how far either control point bends it
+	bend := math.Max(math.Hypot(p0.X-2*p1.X+p2.X, p0.Y-2*p1.Y+p2.Y), math.Hypot(p1.X-2*p2.X+p3.X, p1.Y-2*p2.Y+p3.Y))
+	if depth >= maxFlattenDepth || bend*3/4 <= tolerance {
+		return append(points, p3)
+	}
+	a, b, c := midpoint(p0, p1), midpoint(p1, p2), midpoint(p2, p3)
+	d, e := midpoint(a, b), midpoint(b, c)
+	m := midpoint(d, e)
+	points = flattenCubic(points, p0, a, d, m, tolerance, depth+1)
+	return flattenCubic(points, m, e, c, p3, tolerance, depth+1)
+}
+
+// midpoint finds the point halfway between two points
+func midpoint(a, b Point) Point {
+	return Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+}
@@ -0,0 +1,66 @@
+package font
+
+import (
+	"math"
+	"testing"
+)
+
+// CheckFlattened makes sure every point along a curve is within tolerance of the lines it was flattened into
+func CheckFlattened(t *testing.T, curve func(t float64) Point, lines []Point, tolerance float64) {
+	t.Helper()
+	for step := 0; step <= 1000; step++ {
+		point := curve(float64(step) / 1000)
+		closest := math.Inf(1)
+		for i := 0; i+1 < len(lines); i++ {
+			closest = math.Min(closest, math.Sqrt(distanceToContours([][]Point{{lines[i], lines[i+1]}}, point)))
+		}
+		if closest > tolerance {
+			t.Fatalf("Curve strays %f from its lines at %v", closest, point)
+		}
+	}
+}
+
+func TestFlattenQuadratic(t *testing.T) {
+	p0, p1, p2 := Point{0, 0}, Point{50, 100}, Point{100, 0}
+	quadratic := func(t float64) Point {
+		x, y := QuadraticBézier(t, p0.X, p0.Y, p1.X, p1.Y, p2.X, p2.Y)
+		return Point{x, y}
+	}
+	coarse := FlattenQuadratic([]Point{p0}, p0, p1, p2, 1)
+	fine := FlattenQuadratic([]Point{p0}, p0, p1, p2, 0.01)
+	CheckFlattened(t, quadratic, coarse, 1)
+	CheckFlattened(t, quadratic, fine, 0.01)
+	if len(fine) <= len(coarse) {
+		t.Errorf("Expected a smaller tolerance to use more lines, got %d and %d", len(coarse), len(fine))
+	}
+	if fine[len(fine)-1] != p2 {
+		t.Error("Expected the lines to end at the end of the curve")
+	}
+	if straight := FlattenQuadratic(nil, p0, Point{50, 0}, p2, 0.01); len(straight) != 1 {
+		t.Errorf("Expected a straight curve to be a single line, got %d", len(straight))
+	}
+}
+
+func TestFlattenCubic(t *testing.T) {
+	p0, p1, p2, p3 := Point{0, 0}, Point{0, 100}, Point{100, -100}, Point{100, 0}
+	cubic := func(t float64) Point {
+		x, y := CubicBézier(t, p0.X, p0.Y, p1.X, p1.Y, p2.X, p2.Y, p3.X, p3.Y)
+		return Point{x, y}
+	}
+	CheckFlattened(t, cubic, FlattenCubic([]Point{p0}, p0, p1, p2, p3, 0.1), 0.1)
+}
+
+func TestFlattenGlyphs(t *testing.T) {
+	points := func(size float64) int {
+		fnt := LoadTestFont(t, size)
+		count := 0
+		for _, contour := range fnt.GetGlyph(fnt.Font.Index('O')).GetContours() {
+			count += len(contour)
+		}
+		return count
+	}
+	small, large := points(8), points(256)
+	if small >= large {
+		t.Errorf("Expected bigger glyphs to have more detailed outlines, got %d and %d points", small, large)
+	}
+}
@@ -0,0 +1,191 @@
+package font
+
+import (
+	"errors"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+// FontFile is a parsed font file, with either TrueType outlines made of quadratic curves or OpenType CFF
+// outlines made of cubic curves. Glyphs from either kind are looked up by the same glyph indices.
+type FontFile struct {
+	// TrueType is the parsed font when it has TrueType outlines
+	TrueType *truetype.Font
+	// OpenType is the parsed font when it has CFF outlines
+	OpenType *sfnt.Font
+	buffer   sfnt.Buffer
+	// substitution replaces glyphs with other glyphs while shaping, or is nil if the font has no substitutions
+	substitution *glyphSubstitution
+	// decorations places underlines and strikethroughs
+	decorations decorationMetrics
+	// lineGap is the space the font wants between the descent of one line and the ascent of the next, in
+	// font units, from its hhea table
+	lineGap int
+}
+
+// ParseFont parses the contents of a TrueType or OpenType font file
+func ParseFont(data []byte) (*FontFile, error) {
+	// only OpenType fonts with CFF outlines start with this tag
+	if len(data) >= 4 && string(data[:4]) == "OTTO" {
+		fnt, err := sfnt.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return &FontFile{OpenType: fnt, substitution: parseGlyphSubstitution(data), decorations: parseDecorationMetrics(data), lineGap: parseLineGap(data)}, nil
+	}
+	fnt, err := truetype.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &FontFile{TrueType: fnt, substitution: parseGlyphSubstitution(data), decorations: parseDecorationMetrics(data), lineGap: parseLineGap(data)}, nil
+}
+
+// parseLineGap reads the line gap from a font's hhea table, treating a missing table or a negative gap as no gap
+func parseLineGap(data []byte) int {
+	if gap := int(int16(readUint16(findTable(data, "hhea"), 8))); gap > 0 {
+		return gap
+	}
+	return 0
+}
+
+// Index gets the index of the glyph for a rune, or 0 if the font doesn't have one
+func (file *FontFile) Index(char rune) truetype.Index {
+	if file.OpenType == nil {
+		return file.TrueType.Index(char)
+	}
+	index, err := file.OpenType.GlyphIndex(&file.buffer, char)
+	if err != nil {
+		return 0
+	}
+	return truetype.Index(index)
+}
+
+// Advance gets how far the pen moves after drawing a glyph at the given size in pixels per em. Fully
+// hinted advances are rounded to whole pixels.
+func (file *FontFile) Advance(size fixed.Int26_6, index truetype.Index, hinting font.Hinting) fixed.Int26_6 {
+	if file.OpenType == nil {
+		return roundHinted(file.TrueType.HMetric(size, index).AdvanceWidth, hinting)
+	}
+	advance, err := file.OpenType.GlyphAdvance(&file.buffer, sfnt.GlyphIndex(index), size, horizontalHinting(hinting))
+	if err != nil {
+		return 0
+	}
+	return advance
+}
+
+// Kern gets the adjustment to the space between two glyphs at the given size in pixels per em. Fully
+// hinted kerning is rounded to whole pixels.
+func (file *FontFile) Kern(size fixed.Int26_6, index0, index1 truetype.Index, hinting font.Hinting) fixed.Int26_6 {
+	if file.OpenType == nil {
+		return roundHinted(file.TrueType.Kern(size, index0, index1), hinting)
+	}
+	kern, err := file.OpenType.Kern(&file.buffer, sfnt.GlyphIndex(index0), sfnt.GlyphIndex(index1), size, horizontalHinting(hinting))
+	if err != nil {
+		return 0
+	}
+	return kern
+}
+
+// metrics gets the line metrics of the font at the given size in pixels per em, with the height being the
+// ascent, descent and line gap together. Hinted metrics are rounded up to whole pixels so that baselines
+// land on pixel boundaries.
+func (file *FontFile) metrics(size fixed.Int26_6, hinting font.Hinting) font.Metrics {
+	var metrics font.Metrics
+	var unitsPerEm int
+	if file.OpenType == nil {
+		// at 72 dots per inch, the size in points is the same as the size in pixels
+		metrics = truetype.NewFace(file.TrueType, &truetype.Options{
+			Size: toPixels(size),
+			DPI:  72,
+		}).Metrics()
+		unitsPerEm = int(file.TrueType.FUnitsPerEm())
+	} else {
+		var err error
+		metrics, err = file.OpenType.Metrics(&file.buffer, size, font.HintingNone)
+		if err != nil {
+			return font.Metrics{}
+		}
+		unitsPerEm = int(file.OpenType.UnitsPerEm())
+	}
+	var gap fixed.Int26_6
+	if unitsPerEm > 0 {
+		gap = fixed.Int26_6(int64(file.lineGap) * int64(size) / int64(unitsPerEm))
+	}
+	if hinting != font.HintingNone {
+		metrics.Ascent = fixed.I(metrics.Ascent.Ceil())
+		metrics.Descent = fixed.I(metrics.Descent.Ceil())
+		gap = fixed.I(gap.Ceil())
+	}
+	metrics.Height = metrics.Ascent + metrics.Descent + gap
+	return metrics
+}
+
+// horizontalHinting gets the hinting to use for horizontal measurements, which are only hinted by full hinting
+func horizontalHinting(hinting font.Hinting) font.Hinting {
+	if hinting == font.HintingFull {
+		return hinting
+	}
+	return font.HintingNone
+}
+
+// roundHinted rounds a horizontal measurement to a whole pixel when it is fully hinted
+func roundHinted(value fixed.Int26_6, hinting font.Hinting) fixed.Int26_6 {
+	if hinting == font.HintingFull {
+		return fixed.I(value.Round())
+	}
+	return value
+}
+
+// loadSegments loads the cubic outline of a glyph at the given size from a font with CFF outlines
+func (file *FontFile) loadSegments(index truetype.Index, size fixed.Int26_6) (sfnt.Segments, error) {
+	segments, err := file.OpenType.LoadGlyph(&file.buffer, sfnt.GlyphIndex(index), size, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("Glyph has no outline")
+	}
+	// the segments belong to the buffer, which is reused for the next glyph
+	return append(sfnt.Segments(nil), segments...), nil
+}
+
+// flattenSegments turns each contour of a CFF outline into a closed list of points, flattening each
+// curve to within tolerance
+func flattenSegments(segments sfnt.Segments, tolerance float64) [][]Point {
+	var contours [][]Point
+	var contour []Point
+	var cur Point
+	// outlines from sfnt have y pointing down, unlike TrueType outlines
+	toPoint := func(point fixed.Point26_6) Point {
+		return Point{X: toPixels(point.X), Y: -toPixels(point.Y)}
+	}
+	for _, segment := range segments {
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			if len(contour) > 0 {
+				contours = append(contours, contour)
+			}
+			cur = toPoint(segment.Args[0])
+			contour = []Point{cur}
+		case sfnt.SegmentOpLineTo:
+			cur = toPoint(segment.Args[0])
+			contour = append(contour, cur)
+		case sfnt.SegmentOpQuadTo:
+			to := toPoint(segment.Args[1])
+			contour = FlattenQuadratic(contour, cur, toPoint(segment.Args[0]), to, tolerance)
+			cur = to
+		case sfnt.SegmentOpCubeTo:
+			to := toPoint(segment.Args[2])
+			contour = FlattenCubic(contour, cur, toPoint(segment.Args[0]), toPoint(segment.Args[1]), to, tolerance)
+			cur = to
+		}
+	}
+	if len(contour) > 0 {
+		contours = append(contours, contour)
+	}
+	return contours
+}
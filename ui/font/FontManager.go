@@ -2,30 +2,331 @@ package font
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
-	"unsafe"
 
 	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
 
-	"github.com/go-gl/gl/all-core/gl"
 	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/math/fixed"
 )
 
+// FontStyle is the weight and slant of a face within a font family
+type FontStyle int
+
+const (
+	// Regular is the normal face of a font family
+	Regular FontStyle = 0
+	// Bold is the heavier face of a font family
+	Bold FontStyle = 1
+	// Italic is the slanted face of a font family
+	Italic FontStyle = 2
+	// BoldItalic is the heavier slanted face of a font family
+	BoldItalic FontStyle = 3
+)
+
+// String converts the style to a string
+func (style FontStyle) String() string {
+	switch style {
+	case Regular:
+		return "regular"
+	case Bold:
+		return "bold"
+	case Italic:
+		return "italic"
+	case BoldItalic:
+		return "bold italic"
+	}
+	return fmt.Sprintf("FontStyle(%d)", int(style))
+}
+
+// FontManager keeps track of the font files for each font family and hands out fonts at any size
+type FontManager struct {
+	Families map[string]map[FontStyle]*FontFile
+	// Fallbacks lists, for each font family, the font families used for runes it doesn't have
+	Fallbacks map[string][]string
+	// loaded holds every font that has been handed out, including fonts whose fallbacks can't be loaded
+	// anymore, so that changing the DPI or the font files still updates them
+	loaded map[fontKey]*LoadedFont
+	// unlinked holds why each loaded font is missing some of its fallbacks, so asking for it again fails
+	unlinked map[fontKey]error
+	dpi      float64
+	// generation counts the changes to the fonts that have been handed out
+	generation int
+}
+
+// fontKey identifies a face of a font family at a particular size
+type fontKey struct {
+	family  string
+	style   FontStyle
+	size    float64
+	options FontOptions
+}
+
+// NewFontManager creates a font manager with no font families registered
+func NewFontManager() *FontManager {
+	return &FontManager{
+		Families:  map[string]map[FontStyle]*FontFile{},
+		Fallbacks: map[string][]string{},
+		loaded:    map[fontKey]*LoadedFont{},
+		unlinked:  map[fontKey]error{},
+		dpi:       DefaultDPI,
+	}
+}
+
+// Register parses a font file and adds it to a font family as the face for the given style
+func (manager *FontManager) Register(family string, style FontStyle, path string) error {
+	fnt, err := parseFontFile(path)
+	if err != nil {
+		return err
+	}
+	manager.RegisterFont(family, style, fnt)
+	return nil
+}
+
+// RegisterReader parses a font from a reader and adds it to a font family as the face for the given style
+func (manager *FontManager) RegisterReader(family string, style FontStyle, reader io.Reader) error {
+	fnt, err := parseFontReader(reader)
+	if err != nil {
+		return err
+	}
+	manager.RegisterFont(family, style, fnt)
+	return nil
+}
+
+// RegisterBytes parses a font from its contents and adds it to a font family as the face for the given style
+func (manager *FontManager) RegisterBytes(family string, style FontStyle, data []byte) error {
+	fnt, err := ParseFont(data)
+	if err != nil {
+		return err
+	}
+	manager.RegisterFont(family, style, fnt)
+	return nil
+}
+
+// RegisterFont adds a font that has already been parsed to a font family as the face for the given style.
+// Fonts already loaded from a face it replaces switch to the new face in place.
+func (manager *FontManager) RegisterFont(family string, style FontStyle, fnt *FontFile) {
+	faces, ok := manager.Families[family]
+	if !ok {
+		faces = map[FontStyle]*FontFile{}
+		manager.Families[family] = faces
+	}
+	faces[style] = fnt
+	manager.generation++
+	for key, lfont := range manager.loaded {
+		if key.family == family && key.style == style {
+			lfont.Font = fnt
+			lfont.SetSize(toPixels(lfont.Size))
+		}
+	}
+	manager.linkFallbacks()
+}
+
+// SetFallbacks sets the font families, in order, that fonts from a family fall back to for runes they
+// don't have. Fallback fonts use the same style when the fallback family has it, or regular otherwise.
+// Fonts already loaded from the family switch to the new fallbacks in place.
+func (manager *FontManager) SetFallbacks(family string, fallbacks ...string) {
+	manager.Fallbacks[family] = fallbacks
+	manager.generation++
+	manager.linkFallbacks()
+}
+
+// linkFallbacks points every loaded font at the fallbacks its family has now, loading them if they haven't
+// been loaded yet. Fonts whose fallbacks can't all be loaded keep the ones that can, but asking for them
+// again reports the missing fallback.
+func (manager *FontManager) linkFallbacks() {
+	keys := make([]fontKey, 0, len(manager.loaded))
+	for key := range manager.loaded {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		fallbacks, err := manager.getFallbacks(key)
+		manager.loaded[key].Fallbacks = fallbacks
+		if err != nil {
+			manager.unlinked[key] = err
+		} else {
+			delete(manager.unlinked, key)
+		}
+	}
+}
+
+// getFallbacks loads the fonts a font falls back to, stopping at the first one that can't be loaded
+func (manager *FontManager) getFallbacks(key fontKey) ([]*LoadedFont, error) {
+	var fallbacks []*LoadedFont
+	for _, fallbackFamily := range manager.Fallbacks[key.family] {
+		fallbackStyle := key.style
+		if _, ok := manager.Families[fallbackFamily][key.style]; !ok {
+			fallbackStyle = Regular
+		}
+		fallback, err := manager.GetFontWithOptions(fallbackFamily, fallbackStyle, key.size, key.options)
+		if err != nil {
+			return fallbacks, err
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return fallbacks, nil
+}
+
+// GetGeneration gets a number that changes whenever the fonts the manager has handed out change, by being
+// resized, replaced or given new fallbacks, so that anything laid out with them can tell it is out of date
+func (manager *FontManager) GetGeneration() int {
+	return manager.generation
+}
+
+// GetDPI gets the dots per inch that font sizes in points are converted to pixels with
+func (manager *FontManager) GetDPI() float64 {
+	return manager.dpi
+}
+
+// SetDPI changes the dots per inch that font sizes in points are converted to pixels with, such as when
+// the window moves to a monitor with a different pixel density. Every font that has already been loaded
+// is rescaled in place, so components holding on to them draw at the new size.
+func (manager *FontManager) SetDPI(dpi float64) {
+	manager.dpi = dpi
+	manager.generation++
+	for key, lfont := range manager.loaded {
+		lfont.SetSize(PointsToPixels(key.size, dpi))
+	}
+}
+
+// GetFont gets a face of a font family at the specified size in points, loading it the first time it is
+// asked for
+func (manager *FontManager) GetFont(family string, style FontStyle, size float64) (*LoadedFont, error) {
+	return manager.GetFontWithOptions(family, style, size, FontOptions{})
+}
+
+// GetFontWithOptions gets a face of a font family at the specified size in points with its glyphs fitted to
+// the pixel grid as described by options, loading it the first time it is asked for
+func (manager *FontManager) GetFontWithOptions(family string, style FontStyle, size float64, options FontOptions) (*LoadedFont, error) {
+	key := fontKey{family, style, size, options}
+	if lfont, ok := manager.loaded[key]; ok {
+		if err := manager.unlinked[key]; err != nil {
+			return nil, err
+		}
+		return lfont, nil
+	}
+	faces, ok := manager.Families[family]
+	if !ok {
+		return nil, fmt.Errorf("No font family named %q has been registered", family)
+	}
+	fnt, ok := faces[style]
+	if !ok {
+		return nil, fmt.Errorf("Font family %q has no %s face", family, style)
+	}
+	lfont := NewLoadedFontWithOptions(fnt, PointsToPixels(size, manager.dpi), options)
+	manager.loaded[key] = lfont
+	fallbacks, err := manager.getFallbacks(key)
+	if err != nil {
+		delete(manager.loaded, key)
+		return nil, err
+	}
+	lfont.Fallbacks = fallbacks
+	return lfont, nil
+}
+
 // LoadedFont struct for storing info about each loaded font
 type LoadedFont struct {
-	Font   *truetype.Font
-	Size   fixed.Int26_6
-	Glyphs []GlyphVBO
+	Font *FontFile
+	// Size is the number of pixels per em, which is roughly the height of a line of text
+	Size fixed.Int26_6
+	// Options controls how glyphs are fitted to the pixel grid. Use SetOptions to change them.
+	Options FontOptions
+	// Tolerance is how far in pixels the straight lines making up glyph outlines may stray from their
+	// curves. Changing it only affects glyphs that haven't been used yet.
+	Tolerance float64
+	// Glyphs holds every glyph that has been used so far, keyed by glyph index
+	Glyphs map[truetype.Index]*GlyphVBO
+	Atlas  *Atlas
+	// Fallbacks are the fonts, in order, used for runes this font doesn't have. They should be loaded at
+	// the same size. Line metrics like the ascent and descent always come from this font.
+	Fallbacks []*LoadedFont
+	metrics   font.Metrics
 }
 
-// GlyphVBO stores pointers to OpenGL VBOs and things
+// FontOptions controls how a font's glyphs are fitted to the pixel grid
+type FontOptions struct {
+	// Hinting moves the points of glyph outlines onto pixel boundaries so small text looks sharp.
+	// Vertical hinting only moves points up and down, keeping the shapes and spacing of the glyphs.
+	// Only TrueType outlines are hinted, although the metrics of every font are rounded.
+	Hinting font.Hinting
+	// SubpixelPositions is how many horizontal offsets within a pixel each glyph is rasterized at, so that
+	// glyphs can be drawn between pixels rather than being rounded to the nearest one
+	SubpixelPositions int
+}
+
+// SetOptions changes how the font's glyphs are fitted to the pixel grid, throwing away every glyph that was
+// loaded with the old options
+func (font *LoadedFont) SetOptions(options FontOptions) {
+	font.Options = options
+	font.SetSize(toPixels(font.Size))
+}
+
+// SetSize changes the number of pixels per em of the font in place, throwing away every glyph that was
+// loaded at the old size
+func (font *LoadedFont) SetSize(size float64) {
+	font.Size = toFixed(size)
+	font.Glyphs = map[truetype.Index]*GlyphVBO{}
+	font.Atlas.Clear()
+	font.metrics = font.Font.metrics(font.Size, font.Options.Hinting)
+}
+
+// GetGlyph gets a glyph by its index, loading it the first time it is used. Glyphs that can't be
+// loaded have no outline, so they take up space without drawing anything.
+func (font *LoadedFont) GetGlyph(index truetype.Index) *GlyphVBO {
+	if glyph, ok := font.Glyphs[index]; ok {
+		return glyph
+	}
+	glyph := &GlyphVBO{
+		AdvanceWidth: toPixels(font.Font.Advance(font.Size, index, font.Options.Hinting)),
+		tolerance:    font.Tolerance,
+	}
+	if font.Font.OpenType != nil {
+		if segments, err := font.Font.loadSegments(index, font.Size); err == nil {
+			glyph.Segments = segments
+		}
+	} else if glyphbuf, err := loadGlyph(font.Font.TrueType, index, font.Size, font.Options.Hinting); err == nil {
+		glyph.Glyph = *glyphbuf
+	}
+	font.Glyphs[index] = glyph
+	return glyph
+}
+
+// GlyphVBO stores the outline and metrics of a loaded glyph
 type GlyphVBO struct {
-	GLVAO        *uint32
-	Count        int32
-	Glyph        truetype.GlyphBuf
+	// Glyph is the outline of glyphs from TrueType fonts
+	Glyph truetype.GlyphBuf
+	// Segments is the outline of glyphs from OpenType fonts with CFF outlines
+	Segments     sfnt.Segments
 	AdvanceWidth float64
+	Contours     [][]Point
+	Triangles    []Point
+	tessellated  bool
+	tolerance    float64
+}
+
+// GetContours gets the closed outlines of the glyph relative to its origin, with y pointing up. The curves
+// are flattened the first time they are needed, and the same outlines are used to fill and stroke the glyph.
+func (glyph *GlyphVBO) GetContours() [][]Point {
+	if glyph.Contours == nil {
+		if glyph.Segments != nil {
+			glyph.Contours = flattenSegments(glyph.Segments, glyph.tolerance)
+		} else {
+			glyph.Contours = unpackContours(&glyph.Glyph, glyph.tolerance)
+		}
+	}
+	return glyph.Contours
+}
+
+// GetTriangles gets the triangles that fill the glyph, tessellating its outline the first time it is needed
+func (glyph *GlyphVBO) GetTriangles() []Point {
+	if !glyph.tessellated {
+		glyph.Triangles = Tessellate(glyph.GetContours())
+		glyph.tessellated = true
+	}
+	return glyph.Triangles
 }
 
 // GetIndicesForString gets the glyph indices for each character in the string
@@ -46,201 +347,310 @@ func GetKernsForIndices(font *LoadedFont, indices []truetype.Index) []float64 {
 			last = index
 			continue
 		}
-		fixedkern := font.Font.Kern(font.Size, last, index)
-		kerns = append(kerns, float64(fixedkern))
+		fixedkern := font.Font.Kern(font.Size, last, index, font.Options.Hinting)
+		kerns = append(kerns, toPixels(fixedkern))
 		last = index
 	}
 	kerns = append(kerns, 0.0)
 	return kerns
 }
 
-// DrawSlowString draws a string slowly by calculating and unpacking vertices on the fly
-func DrawSlowString(font *LoadedFont, str string, x, y float64) {
-	// get glyph indices for the runes in the string
-	indices := GetIndicesForString(font, str)
+// Point is a position on a glyph outline
+type Point struct {
+	X float64
+	Y float64
+}
 
-	// get kernings for the indices
-	kerns := GetKernsForIndices(font, indices)
-	dx := 0.0
+// PositionedGlyph is a glyph placed relative to the start of a run of text, with y pointing down
+type PositionedGlyph struct {
+	// Font is the font the glyph comes from, which is one of the fallbacks when the font being drawn with
+	// doesn't have the glyph. Glyphs without a font come from the font being drawn with.
+	Font  *LoadedFont
+	Index truetype.Index
+	// X and Y are the position of the glyph's origin on the baseline
+	X float64
+	Y float64
+	// Offset is the byte offset in the source string of the rune the glyph was made from
+	Offset int
+}
 
-	// draw each glyph
-	for i, index := range indices {
-		firstOnPoint := true
-		var curPoints []truetype.Point
-		glyphBuf := font.Glyphs[index].Glyph
-		glyphPoints := make([]truetype.Point, len(glyphBuf.Points)+len(glyphBuf.Ends))
-		glyphEnds := make([]int, len(glyphBuf.Ends))
-
-		// expand points to make complete contours
-		for end := range glyphBuf.Ends {
-			if end == 0 {
-				copy(glyphPoints[:glyphBuf.Ends[end]+end], glyphBuf.Points[:glyphBuf.Ends[end]])
-				glyphPoints[glyphBuf.Ends[end]+end] = glyphBuf.Points[0]
-			} else {
-				copy(glyphPoints[glyphBuf.Ends[end-1]+end:glyphBuf.Ends[end]+end], glyphBuf.Points[glyphBuf.Ends[end-1]:glyphBuf.Ends[end]])
-				glyphPoints[glyphBuf.Ends[end]+end] = glyphBuf.Points[glyphBuf.Ends[end-1]]
+// PositionGlyphs shapes a string into glyphs placed along a single baseline starting at the origin, and
+// returns the glyphs from left to right along with how far the pen moved. Each rune is drawn with the first
+// font in the fallback chain that has it, and glyphs are only kerned against glyphs from the same font.
+// See ShapeText for how runes are reordered, joined and combined.
+func PositionGlyphs(font *LoadedFont, str string) ([]PositionedGlyph, float64) {
+	shaped := ShapeText(font, str)
+	return shaped.Glyphs, shaped.Advance
+}
+
+// GetFontForRune finds the first font in the fallback chain that has a glyph for the rune, and the index
+// of that glyph. A font's own fallbacks are tried before the fallbacks of those fallbacks, and fonts that
+// the chain loops back to are only tried once. If no font in the chain has the rune, the missing glyph from
+// this font is used.
+func (font *LoadedFont) GetFontForRune(char rune) (*LoadedFont, truetype.Index) {
+	visited := map[*LoadedFont]bool{font: true}
+	queue := []*LoadedFont{font}
+	for len(queue) > 0 {
+		candidate := queue[0]
+		queue = queue[1:]
+		if index := candidate.Font.Index(char); index != 0 {
+			return candidate, index
+		}
+		for _, fallback := range candidate.Fallbacks {
+			if !visited[fallback] {
+				visited[fallback] = true
+				queue = append(queue, fallback)
+			}
+		}
+	}
+	return font, 0
+}
+
+// getFont gets the font a positioned glyph comes from
+func (glyph PositionedGlyph) getFont(font *LoadedFont) *LoadedFont {
+	if glyph.Font != nil {
+		return glyph.Font
+	}
+	return font
+}
+
+// GetContoursForString calculates the closed outline of each glyph in the string as lists of
+// connected points, with the baseline of the string starting at x, y
+func GetContoursForString(font *LoadedFont, str string, x, y float64) [][]Point {
+	glyphs, _ := PositionGlyphs(font, str)
+	return GetContoursForGlyphs(font, glyphs, x, y)
+}
+
+// GetContoursForGlyphs calculates the closed outline of each glyph as lists of connected points, with
+// the glyphs positioned relative to x, y
+func GetContoursForGlyphs(font *LoadedFont, glyphs []PositionedGlyph, x, y float64) [][]Point {
+	var contours [][]Point
+	for _, positioned := range glyphs {
+		for _, contour := range positioned.getFont(font).GetGlyph(positioned.Index).GetContours() {
+			placed := make([]Point, len(contour))
+			for i, point := range contour {
+				placed[i] = Point{X: x + positioned.X + point.X, Y: y + positioned.Y - point.Y}
 			}
-			glyphEnds[end] = glyphBuf.Ends[end] + end
+			contours = append(contours, placed)
 		}
+	}
+	return contours
+}
+
+// GetTrianglesForString calculates the triangles that fill each glyph in the string, three points per
+// triangle, with the baseline of the string starting at x, y
+func GetTrianglesForString(font *LoadedFont, str string, x, y float64) []Point {
+	glyphs, _ := PositionGlyphs(font, str)
+	return GetTrianglesForGlyphs(font, glyphs, x, y)
+}
 
-		// draw contours
-		var endIndex int
-		for b, point := range glyphPoints {
-			if b == glyphEnds[endIndex]+1 {
-				endIndex++
-				firstOnPoint = true
-				curPoints = []truetype.Point{}
+// GetTrianglesForGlyphs calculates the triangles that fill each glyph, three points per triangle, with
+// the glyphs positioned relative to x, y
+func GetTrianglesForGlyphs(font *LoadedFont, glyphs []PositionedGlyph, x, y float64) []Point {
+	var triangles []Point
+	for _, positioned := range glyphs {
+		for _, point := range positioned.getFont(font).GetGlyph(positioned.Index).GetTriangles() {
+			triangles = append(triangles, Point{X: x + positioned.X + point.X, Y: y + positioned.Y - point.Y})
+		}
+	}
+	return triangles
+}
+
+// unpackContours turns each contour of a glyph into a closed list of points, expanding the implied
+// on-curve points between consecutive off-curve points and flattening each quadratic bézier curve to
+// within tolerance
+func unpackContours(glyphBuf *truetype.GlyphBuf, tolerance float64) [][]Point {
+	var contours [][]Point
+	start := 0
+	for _, end := range glyphBuf.Ends {
+		points := glyphBuf.Points[start:end]
+		start = end
+		if len(points) == 0 {
+			continue
+		}
+
+		// start from an on-curve point, or in between the last and first points if there isn't one
+		first := -1
+		for i, point := range points {
+			if point.Flags&1 != 0 {
+				first = i
+				break
 			}
+		}
+		var cur Point
+		if first >= 0 {
+			cur = Point{X: toPixels(points[first].X), Y: toPixels(points[first].Y)}
+			first++
+		} else {
+			last := points[len(points)-1]
+			cur.X, cur.Y = LinearBézier(0.5, toPixels(last.X), toPixels(last.Y), toPixels(points[0].X), toPixels(points[0].Y))
+			first = 0
+		}
 
-			if point.Flags&1 == 0 {
-				curPoints = append(curPoints, point)
-			} else if firstOnPoint == false {
-				curPoints = append(curPoints, point)
-
-				gl.Color3d(1, 1, 1)
-				gl.Begin(gl.LINE_STRIP)
-				for d := 0.0; d <= 1.0; d += 0.1 {
-
-					if len(curPoints) == 2 {
-						_x, _y := LinearBézier(d, float64(curPoints[0].X), float64(curPoints[0].Y), float64(curPoints[1].X), float64(curPoints[1].Y))
-						gl.Vertex2d(x+_x+dx, y-_y)
-					} else if len(curPoints) == 3 {
-						_x, _y := QuadraticBézier(d, float64(curPoints[0].X), float64(curPoints[0].Y), float64(curPoints[1].X), float64(curPoints[1].Y), float64(curPoints[2].X), float64(curPoints[2].Y))
-						gl.Vertex2d(x+_x+dx, y-_y)
-					} else {
-						_x, _y := UnpackBézier(d, curPoints)
-						gl.Vertex2d(x+_x+dx, y-_y)
-					}
+		contour := []Point{cur}
+		var ctrl Point
+		hasCtrl := false
+		curve := func(to Point) {
+			contour = FlattenQuadratic(contour, cur, ctrl, to, tolerance)
+			cur = to
+		}
+		for i := 0; i < len(points); i++ {
+			point := points[(first+i)%len(points)]
+			next := Point{X: toPixels(point.X), Y: toPixels(point.Y)}
+			if point.Flags&1 != 0 {
+				if hasCtrl {
+					curve(next)
+				} else {
+					contour = append(contour, next)
+					cur = next
 				}
-				gl.End()
-				curPoints = []truetype.Point{point}
-				firstOnPoint = false
+				hasCtrl = false
 			} else {
-				firstOnPoint = false
-				curPoints = append(curPoints, point)
+				if hasCtrl {
+					midX, midY := LinearBézier(0.5, ctrl.X, ctrl.Y, next.X, next.Y)
+					curve(Point{X: midX, Y: midY})
+				}
+				ctrl = next
+				hasCtrl = true
 			}
 		}
-		dx += font.Glyphs[index].AdvanceWidth + kerns[i]
-
+		if hasCtrl {
+			curve(contour[0])
+		}
+		contours = append(contours, contour)
 	}
+	return contours
 }
 
-// LoadFont loads a font at the specified scale
-func LoadFont(path string, scale float64) (*LoadedFont, error) {
+// LoadFont loads a TrueType or OpenType font at the specified size in pixels per em. Sizes in points can
+// be converted with PointsToPixels.
+func LoadFont(path string, size float64) (*LoadedFont, error) {
+	return LoadFontWithOptions(path, size, FontOptions{})
+}
+
+// LoadFontWithOptions loads a TrueType or OpenType font at the specified size in pixels per em, fitting
+// its glyphs to the pixel grid as described by options
+func LoadFontWithOptions(path string, size float64, options FontOptions) (*LoadedFont, error) {
+
+	fnt, err := parseFontFile(path)
 
-	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	strct, err := file.Stat()
+	return NewLoadedFontWithOptions(fnt, size, options), nil
 
-	if err != nil {
-		return nil, err
+}
+
+// NewLoadedFont creates a loaded font at the specified size in pixels per em from a font that has already
+// been parsed, so that several sizes of the same font can share it
+func NewLoadedFont(fnt *FontFile, size float64) *LoadedFont {
+	return NewLoadedFontWithOptions(fnt, size, FontOptions{})
+}
+
+// NewLoadedFontWithOptions creates a loaded font at the specified size in pixels per em from a font that
+// has already been parsed, fitting its glyphs to the pixel grid as described by options
+func NewLoadedFontWithOptions(fnt *FontFile, size float64, options FontOptions) *LoadedFont {
+	lfont := LoadedFont{
+		Font:      fnt,
+		Size:      toFixed(size),
+		Options:   options,
+		Tolerance: DefaultTolerance,
+		Glyphs:    map[truetype.Index]*GlyphVBO{},
+		Atlas:     NewAtlas(DefaultAtlasPageSize, DefaultAtlasMaxPages),
 	}
+	lfont.metrics = fnt.metrics(lfont.Size, options.Hinting)
 
-	filedata := make([]byte, strct.Size())
+	return &lfont
+}
+
+// LoadFontFromReader loads a font from a reader at the specified size in pixels per em
+func LoadFontFromReader(reader io.Reader, size float64) (*LoadedFont, error) {
 
-	_, err = io.ReadFull(file, filedata)
+	fnt, err := parseFontReader(reader)
 
 	if err != nil {
 		return nil, err
 	}
 
-	fnt, err := truetype.Parse(filedata)
+	return NewLoadedFont(fnt, size), nil
+
+}
+
+// LoadFontFromBytes loads a font from the contents of a font file at the specified size in pixels per em
+func LoadFontFromBytes(data []byte, size float64) (*LoadedFont, error) {
+
+	fnt, err := ParseFont(data)
 
 	if err != nil {
 		return nil, err
 	}
 
-	hint := font.HintingNone
+	return NewLoadedFont(fnt, size), nil
 
-	lfont := LoadedFont{
-		Font:   fnt,
-		Size:   fixed.Int26_6(scale),
-		Glyphs: []GlyphVBO{},
-	}
-	for i := 0; i < 4096; i++ {
-		vao, cnt, err := buildGlyph(fnt, i, fixed.Int26_6(scale), hint)
-		if err != nil {
-			continue
-		}
-		glyphbuf := truetype.GlyphBuf{}
-		err = glyphbuf.Load(fnt, fixed.Int26_6(scale), truetype.Index(i), hint)
-		if err != nil {
-			continue
-		}
-		glyphvbo := GlyphVBO{
-			GLVAO:        vao,
-			Count:        cnt,
-			Glyph:        glyphbuf,
-			AdvanceWidth: float64(fnt.HMetric(fixed.Int26_6(scale), truetype.Index(i)).AdvanceWidth),
-		}
-		lfont.Glyphs = append(lfont.Glyphs, glyphvbo)
+}
+
+// parseFontFile reads and parses a font file
+func parseFontFile(path string) (*FontFile, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	return &lfont, nil
+	return parseFontReader(file)
 
 }
 
-// buildGlyph builds a glyph VBO for the specified font
-func buildGlyph(font *truetype.Font, index int, scale fixed.Int26_6, hint font.Hinting) (*uint32, int32, error) {
+// parseFontReader reads everything from a reader and parses it as a font
+func parseFontReader(reader io.Reader) (*FontFile, error) {
 
-	vao := [1]uint32{}
-	gl.GenVertexArrays(1, &vao[0])
-	gl.BindVertexArray(vao[0])
+	filedata, err := io.ReadAll(reader)
 
-	var quit bool
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseFont(filedata)
 
+}
+
+// loadGlyph loads the outline of a glyph, guarding against glyphs that make the parser panic
+func loadGlyph(fnt *truetype.Font, index truetype.Index, scale fixed.Int26_6, hint font.Hinting) (glyphbuf *truetype.GlyphBuf, err error) {
 	defer func() {
 		if recover() != nil {
-			quit = true
+			glyphbuf = nil
+			err = errors.New("Could not load glyph")
 		}
 	}()
 
-	glyphbuf := truetype.GlyphBuf{}
-	err := glyphbuf.Load(font, scale, truetype.Index(index), hint)
-
-	if quit {
-		return nil, 0, errors.New("Could not load glyph")
+	glyphbuf = &truetype.GlyphBuf{}
+	if hint != font.HintingVertical {
+		err = glyphbuf.Load(fnt, scale, index, hint)
+		if err != nil {
+			return nil, err
+		}
+		return glyphbuf, nil
 	}
 
+	// the hinting program always moves points both ways, so put back where the points were horizontally
+	err = glyphbuf.Load(fnt, scale, index, font.HintingNone)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-
-	vbo := [1]uint32{}
-	gl.GenBuffers(1, &vbo[0])
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo[0])
-
-	size := int32(len(glyphbuf.Points))
-
-	for _, pt := range glyphbuf.Points {
-		gpt := [2]float64{float64(pt.X), float64(pt.Y)}
-		gl.BufferData(vbo[0], 2, unsafe.Pointer(&gpt[0]), gl.STATIC_DRAW)
+	hinted := &truetype.GlyphBuf{}
+	err = hinted.Load(fnt, scale, index, font.HintingFull)
+	if err != nil {
+		return nil, err
 	}
-
-	indices := []int32{}
-	lastindex := int32(0)
-	for _, index := range glyphbuf.Ends {
-		for i := lastindex; i < int32(index); i++ {
-			indices = append(indices, i, i+1)
+	if len(hinted.Points) == len(glyphbuf.Points) {
+		for i := range glyphbuf.Points {
+			glyphbuf.Points[i].Y = hinted.Points[i].Y
 		}
 	}
 
-	gl.VertexAttribPointer(0, size, gl.DOUBLE, false, 0, unsafe.Pointer(nil))
-	gl.EnableVertexAttribArray(0)
-
-	// generate VBO indices
-	//vboIndices := [1]uint32{}
-	//gl.GenBuffers(1, &vboIndices[0])
-	//gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, vboIndices[0])
-	//gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices), unsafe.Pointer(&indices[0]), gl.STATIC_DRAW)
-
-	gl.BindVertexArray(0)
-
-	return &vao[0], size, nil
+	return glyphbuf, nil
 
 }
 
@@ -264,63 +674,3 @@ func CubicBézier(t, p0x, p0y, p1x, p1y, p2x, p2y, p3x, p3y float64) (float64, f
 	y := (1-t)*(1-t)*(1-t)*p0y + 3*(1-t)*(1-t)*t*p1y + 3*(1-t)*t*t*p2y + t*t*t*p3y
 	return x, y
 }
-
-// UnpackBézier turns the incomplete bézier curves into their respective complete curves
-func UnpackBézier(t float64, points []truetype.Point) (float64, float64) {
-	subcurves := len(points) - 2
-	newpoints := []truetype.Point{points[0]}
-	for i := 1; i < len(points)-2; i++ {
-		newpoints = append(newpoints, points[i])
-		midx, midy := LinearBézier(0.5, float64(points[i].X), float64(points[i].Y), float64(points[i+1].X), float64(points[i+1].Y))
-		newpoints = append(newpoints, truetype.Point{
-			X:     fixed.Int26_6(midx),
-			Y:     fixed.Int26_6(midy),
-			Flags: 1,
-		})
-	}
-	newpoints = append(newpoints, points[len(points)-2])
-	newpoints = append(newpoints, points[len(points)-1])
-	curveindex := int32(t * float64(subcurves))
-	x, y := QuadraticBézier(t*float64(subcurves)-float64(curveindex), float64(newpoints[curveindex*2].X), float64(newpoints[curveindex*2].Y), float64(newpoints[curveindex*2+1].X), float64(newpoints[curveindex*2+1].Y), float64(newpoints[curveindex*2+2].X), float64(newpoints[curveindex*2+2].Y))
-
-	return x, y
-}
-
-/*func UnpackBézier2(points []truetype.Point) []truetype.Point {
-	//subcurves := len(points) - 2
-	newpoints := []truetype.Point{points[0]}
-	for i := 1; i < len(points)-2; i++ {
-		newpoints = append(newpoints, points[i])
-		midx, midy := LinearBézier(0.5, float64(points[i].X), float64(points[i].Y), float64(points[i+1].X), float64(points[i+1].Y))
-		newpoints = append(newpoints, truetype.Point{
-			X:     fixed.Int26_6(midx),
-			Y:     fixed.Int26_6(midy),
-			Flags: 1,
-		})
-	}
-	newpoints = append(newpoints, points[len(points)-2])
-	newpoints = append(newpoints, points[len(points)-1])
-	return newpoints
-}
-
-func Bézier(t float64, points []truetype.Point) (float64, float64) {
-	var x, y float64
-	order := len(points) - 1
-	for i := 0; i <= order; i++ {
-		coeff := float64(Binomial(int32(order), int32(i))) * math.Pow(1-t, float64(order-i)) * math.Pow(t, float64(i))
-		x += coeff * float64(points[i].X)
-		y += coeff * float64(points[i].Y)
-	}
-	return x, y
-}
-
-func Binomial(n, k int32) int32 {
-	return Factorial(n) / (Factorial(k) * Factorial(n-k))
-}
-
-func Factorial(x int32) int32 {
-	if x <= 1 {
-		return 1
-	}
-	return x * Factorial(x-1)
-}*/
@@ -0,0 +1,365 @@
+package font
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+func LoadTestFont(t *testing.T, size float64) *LoadedFont {
+	fnt, err := LoadDefaultFont(size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fnt
+}
+
+func LoadTestManager(t *testing.T) *FontManager {
+	manager := NewFontManager()
+	if err := manager.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func TestLazyGlyphs(t *testing.T) {
+	fnt := LoadTestFont(t, 24)
+	if len(fnt.Glyphs) != 0 {
+		t.Errorf("Expected no glyphs to be loaded up front, got %d", len(fnt.Glyphs))
+	}
+	GetContoursForString(fnt, "abba", 0, 0)
+	if len(fnt.Glyphs) != 2 {
+		t.Errorf("Expected 2 glyphs to be loaded, got %d", len(fnt.Glyphs))
+	}
+	index := fnt.Font.Index('b')
+	glyph, ok := fnt.Glyphs[index]
+	if !ok {
+		t.Fatal("Expected glyphs to be keyed by glyph index")
+	}
+	if glyph != fnt.GetGlyph(index) {
+		t.Error("Expected loaded glyphs to be reused")
+	}
+	if len(glyph.Glyph.Points) == 0 || glyph.AdvanceWidth <= 0 {
+		t.Error("Expected the glyph to have an outline and an advance")
+	}
+}
+
+func TestFontManager(t *testing.T) {
+	manager := NewFontManager()
+	if err := manager.Register("Go", Regular, "fonts/Go-Regular.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Register("Go", Bold, "fonts/Go-Bold.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Register("Go", Italic, "testdata/missing.ttf"); err == nil {
+		t.Error("Expected an error registering a missing file")
+	}
+
+	small, err := manager.GetFont("Go", Regular, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large, err := manager.GetFont("Go", Regular, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small == large || small.Font != large.Font {
+		t.Error("Expected different sizes to be separate fonts sharing the parsed font file")
+	}
+	if again, _ := manager.GetFont("Go", Regular, 12); again != small {
+		t.Error("Expected the same size to reuse the loaded font")
+	}
+	bold, err := manager.GetFont("Go", Bold, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bold.Font == small.Font {
+		t.Error("Expected the bold face to use its own font file")
+	}
+
+	if _, err := manager.GetFont("Go", Italic, 12); err == nil || err.Error() != `Font family "Go" has no italic face` {
+		t.Errorf("Invalid error for a missing face: %v", err)
+	}
+	if _, err := manager.GetFont("Arial", Regular, 12); err == nil {
+		t.Error("Expected an error for a missing family")
+	}
+}
+
+func TestFontManagerDPI(t *testing.T) {
+	manager := LoadTestManager(t)
+	fnt, err := manager.GetFont("Go", Regular, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manager.GetDPI() != DefaultDPI || fnt.Size != fixed.I(12) {
+		t.Errorf("Expected points to be the same as pixels by default, got %v", fnt.Size)
+	}
+	advance := fnt.GetAdvance("Mars")
+	DrawString(fnt, "Mars", 0, 0)
+
+	manager.SetDPI(144)
+	if again, _ := manager.GetFont("Go", Regular, 12); again != fnt {
+		t.Error("Expected the font to be rescaled in place")
+	}
+	if fnt.Size != fixed.I(24) || len(fnt.Glyphs) != 0 || len(fnt.Atlas.entries) != 0 {
+		t.Error("Expected the font to be reloaded at twice the size")
+	}
+	if rescaled := fnt.GetAdvance("Mars"); math.Abs(rescaled-2*advance) > 4.0/64 {
+		t.Errorf("Expected the advance to double, got %f and %f", advance, rescaled)
+	}
+	if larger, _ := manager.GetFont("Go", Regular, 18); larger.Size != fixed.I(36) {
+		t.Errorf("Expected new fonts to be loaded at the new DPI, got %v", larger.Size)
+	}
+}
+
+func TestFontManagerKeepsLoadedFonts(t *testing.T) {
+	manager := NewFontManager()
+	if err := manager.Register("Go", Regular, "fonts/Go-Regular.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	fnt, err := manager.GetFont("Go", Regular, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Register("Go", Bold, "fonts/Go-Bold.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Register("Test", Regular, "testdata/glyfTest.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetFallbacks("Go", "Test")
+	manager.SetDPI(144)
+	if fnt.Size != fixed.I(24) {
+		t.Errorf("Expected the font to be rescaled after registering other fonts, got %v", fnt.Size)
+	}
+	if again, _ := manager.GetFont("Go", Regular, 12); again != fnt {
+		t.Error("Expected the same font to be handed out after registering other fonts")
+	}
+	test, _ := manager.GetFont("Test", Regular, 12)
+	if len(fnt.Fallbacks) != 1 || fnt.Fallbacks[0] != test {
+		t.Error("Expected the loaded font to pick up its new fallbacks")
+	}
+
+	// replacing a face switches the fonts loaded from it over in place
+	if err := manager.Register("Go", Regular, "fonts/Go-Bold.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	if fnt.Font != manager.Families["Go"][Regular] || fnt.Size != fixed.I(24) {
+		t.Error("Expected the loaded font to use the new face")
+	}
+}
+
+func TestLoadFontFromReader(t *testing.T) {
+	file, err := os.Open("testdata/Go-Italic.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	fnt, err := LoadFontFromReader(file, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fnt.GetAdvance("italic") <= 0 {
+		t.Error("Expected the font to measure text")
+	}
+	if _, err := LoadFontFromBytes([]byte("not a font"), 16); err == nil {
+		t.Error("Expected an error loading garbage")
+	}
+}
+
+func TestDefaultFonts(t *testing.T) {
+	fnt, err := LoadDefaultFont(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fnt.GetAdvance("Mars") <= 0 {
+		t.Error("Expected the default font to measure text")
+	}
+	manager := NewFontManager()
+	if err := manager.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.GetFont(DefaultFamily, Bold, 16); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	manager := LoadTestManager(t)
+	if err := manager.Register("Test", Regular, "testdata/glyfTest.ttf"); err != nil {
+		t.Fatal(err)
+	}
+	manager.SetFallbacks("Test", "Go")
+
+	fnt, err := manager.GetFont("Test", Regular, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goFont, _ := manager.GetFont("Go", Regular, 24)
+	if len(fnt.Fallbacks) != 1 || fnt.Fallbacks[0] != goFont {
+		t.Fatal("Expected the font to fall back to the loaded Go font")
+	}
+
+	glyphs, advance := PositionGlyphs(fnt, "1a\u0fff")
+	if glyphs[0].Font != fnt || glyphs[0].Index != fnt.Font.Index('1') {
+		t.Error("Expected runes the font has to come from the font itself")
+	}
+	if glyphs[1].Font != goFont || glyphs[1].Index != goFont.Font.Index('a') {
+		t.Error("Expected runes the font doesn't have to come from the fallback")
+	}
+	if glyphs[2].Font != fnt || glyphs[2].Index != 0 {
+		t.Error("Expected runes no font has to use the missing glyph of the font itself")
+	}
+	if glyphs[1].X != fnt.GetAdvance("1") {
+		t.Errorf("Expected the fallback glyph to follow the first glyph, got %f", glyphs[1].X)
+	}
+	expected := fnt.GetAdvance("1") + goFont.GetAdvance("a") + fnt.GetGlyph(0).AdvanceWidth
+	if advance != expected {
+		t.Errorf("Invalid advance %f, expected %f", advance, expected)
+	}
+	if len(DrawGlyphs(fnt, glyphs, 0, 0)) != 3 || len(goFont.Atlas.entries) != 1 {
+		t.Error("Expected fallback glyphs to be drawn from the fallback font's atlas")
+	}
+
+	// styles the fallback family doesn't have fall back to its regular face
+	manager.Register("Test", Bold, "testdata/glyfTest.ttf")
+	manager.Register("Test", Italic, "testdata/glyfTest.ttf")
+	bold, _ := manager.GetFont("Test", Bold, 24)
+	if bold.Fallbacks[0].Font != manager.Families["Go"][Bold] {
+		t.Error("Expected the bold font to fall back to the bold face")
+	}
+	italic, _ := manager.GetFont("Test", Italic, 24)
+	if italic.Fallbacks[0].Font != manager.Families["Go"][Regular] {
+		t.Error("Expected the italic font to fall back to the regular face")
+	}
+
+	manager.SetFallbacks("Test", "Arial")
+	if _, err := manager.GetFont("Test", Regular, 24); err == nil {
+		t.Error("Expected an error for a missing fallback family")
+	}
+	// the font keeps being the same font once its fallbacks can be loaded again
+	manager.SetFallbacks("Test", "Go")
+	if again, err := manager.GetFont("Test", Regular, 24); err != nil || again != fnt || len(manager.loaded) != 5 {
+		t.Errorf("Expected the same font back without loading more fonts, got %v with %d fonts loaded", err, len(manager.loaded))
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	manager := LoadTestManager(t)
+	for family, path := range map[string]string{"Test": "testdata/glyfTest.ttf", "Digits": "testdata/glyfTest.ttf", "Slanted": "testdata/Go-Italic.ttf"} {
+		if err := manager.Register(family, Regular, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the chain loops back to the first font, which has to be skipped
+	manager.SetFallbacks("Test", "Digits")
+	manager.SetFallbacks("Digits", "Go")
+	manager.SetFallbacks("Go", "Slanted")
+	manager.SetFallbacks("Slanted", "Test")
+	fnt, err := manager.GetFont("Test", Regular, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goFont, _ := manager.GetFont("Go", Regular, 16)
+	if found, index := fnt.GetFontForRune('a'); found != goFont || index != goFont.Font.Index('a') {
+		t.Error("Expected the rune to come from the fallback of the fallback before the ones after it")
+	}
+	if found, index := fnt.GetFontForRune('\U0001F680'); found != fnt || index != 0 {
+		t.Error("Expected the missing glyph of the font itself when no font has the rune")
+	}
+}
+
+func TestOpenTypeFont(t *testing.T) {
+	fnt, err := LoadFont("testdata/CFFTest.otf", 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fnt.Font.OpenType == nil || fnt.Font.TrueType != nil {
+		t.Fatal("Expected the font to be loaded as an OpenType font")
+	}
+	index := fnt.Font.Index('Q')
+	if index == 0 || fnt.Font.Index('a') != 0 {
+		t.Error("Invalid glyph indices")
+	}
+	glyph := fnt.GetGlyph(index)
+	cubic := false
+	for _, segment := range glyph.Segments {
+		cubic = cubic || segment.Op == sfnt.SegmentOpCubeTo
+	}
+	if !cubic {
+		t.Fatal("Expected the glyph to have cubic curves")
+	}
+	if glyph.AdvanceWidth <= 0 || fnt.GetAscent() <= 0 {
+		t.Error("Expected the font to have metrics")
+	}
+
+	// the outline is flattened and filled just like a TrueType outline
+	area := 0.0
+	for _, contour := range glyph.GetContours() {
+		area += contourArea(contour)
+	}
+	CheckArea(t, glyph.GetTriangles(), math.Abs(area))
+	bounds := fnt.GetInkBounds("Q")
+	if bounds.Y >= 0 || math.Abs(bounds.Y+bounds.Height) > 1 {
+		t.Errorf("Expected the glyph to sit on the baseline, got %v", bounds)
+	}
+	if quads := DrawString(fnt, "01Q", 0, 0); len(quads) != 3 {
+		t.Errorf("Expected every glyph to be drawn from the atlas, got %d quads", len(quads))
+	}
+}
+
+func TestHinting(t *testing.T) {
+	unhinted := LoadTestFont(t, 13)
+	full, err := LoadFontWithOptions("fonts/Go-Regular.ttf", 13, FontOptions{Hinting: font.HintingFull})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vertical := NewLoadedFontWithOptions(full.Font, 13, FontOptions{Hinting: font.HintingVertical})
+	for _, char := range "aHgW" {
+		if advance := full.GetAdvance(string(char)); advance != math.Floor(advance) {
+			t.Errorf("Expected fully hinted %q to advance by whole pixels, got %f", char, advance)
+		}
+		if vertical.GetAdvance(string(char)) != unhinted.GetAdvance(string(char)) {
+			t.Errorf("Expected vertical hinting not to change the advance of %q", char)
+		}
+	}
+	if full.GetAscent() != math.Ceil(unhinted.GetAscent()) {
+		t.Errorf("Expected the hinted ascent to be rounded up to %f, got %f", math.Ceil(unhinted.GetAscent()), full.GetAscent())
+	}
+
+	index := full.Font.Index('H')
+	before := unhinted.GetGlyph(index).Glyph.Points
+	after := vertical.GetGlyph(index).Glyph.Points
+	if len(before) != len(after) {
+		t.Fatalf("Expected hinting to keep %d points, got %d", len(before), len(after))
+	}
+	top := fixed.Int26_6(math.MinInt32)
+	for i := range after {
+		if after[i].X != before[i].X {
+			t.Errorf("Expected vertical hinting not to move point %d sideways", i)
+		}
+		if after[i].Y > top {
+			top = after[i].Y
+		}
+	}
+	if top != fixed.I(top.Round()) {
+		t.Errorf("Expected the top of the H to be hinted onto a pixel boundary, got %v", top)
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	fnt := LoadTestFont(t, 13)
+	DrawString(fnt, "abc", 0, 0)
+	fnt.SetOptions(FontOptions{Hinting: font.HintingFull, SubpixelPositions: 4})
+	if len(fnt.Glyphs) != 0 || len(fnt.Atlas.entries) != 0 {
+		t.Error("Expected changing the options to throw away the loaded glyphs")
+	}
+	if advance := fnt.GetAdvance("a"); advance != math.Floor(advance) {
+		t.Errorf("Expected the new options to be used, got an advance of %f", advance)
+	}
+}
@@ -0,0 +1,331 @@
+package font
+
+import (
+	"encoding/binary"
+
+	"github.com/golang/freetype/truetype"
+)
+
+const (
+	// lookupIgnoreBaseGlyphs, lookupIgnoreLigatures and lookupIgnoreMarks are lookup flags that make a
+	// lookup skip over glyphs of a class when matching
+	lookupIgnoreBaseGlyphs = 0x2
+	lookupIgnoreLigatures  = 0x4
+	lookupIgnoreMarks      = 0x8
+	// glyphClassBase, glyphClassLigature and glyphClassMark are the glyph classes from the GDEF table
+	glyphClassBase     = 1
+	glyphClassLigature = 2
+	glyphClassMark     = 3
+)
+
+// glyphSubstitution reads the lookups from a font's GSUB table that replace glyphs with other glyphs,
+// like the joining forms of Arabic letters and ligatures. Only single and ligature substitutions are
+// supported, and every read is bounds checked so broken tables only stop substitutions from happening.
+type glyphSubstitution struct {
+	gsub []byte
+	// classes is the glyph class definition table from the GDEF table, if the font has one
+	classes []byte
+}
+
+// substitutedGlyph is a glyph going through substitution, along with the indices of the runes it was made
+// from among the runes being shaped
+type substitutedGlyph struct {
+	index      truetype.Index
+	components []int
+	// feature is the joining form feature that applies to the glyph, if any
+	feature string
+}
+
+// parseGlyphSubstitution finds the GSUB and GDEF tables of a font, returning nil if it has no GSUB table
+func parseGlyphSubstitution(data []byte) *glyphSubstitution {
+	gsub := findTable(data, "GSUB")
+	if gsub == nil {
+		return nil
+	}
+	substitution := &glyphSubstitution{gsub: gsub}
+	if gdef := findTable(data, "GDEF"); gdef != nil {
+		substitution.classes = subtable(gdef, int(readUint16(gdef, 4)))
+	}
+	return substitution
+}
+
+// findTable finds a table in the table directory of a font file
+func findTable(data []byte, tag string) []byte {
+	count := int(readUint16(data, 4))
+	for i := 0; i < count; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) || string(data[record:record+4]) != tag {
+			continue
+		}
+		offset, length := readUint32(data, record+8), readUint32(data, record+12)
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil
+		}
+		return data[offset : offset+length]
+	}
+	return nil
+}
+
+// featureLookup is a lookup that is applied as part of one or more features
+type featureLookup struct {
+	index    int
+	features map[string]bool
+}
+
+// joiningOnly determines whether a lookup is only used to pick joining forms, so it should only start at
+// glyphs that take one of its forms
+func (lookup featureLookup) joiningOnly() bool {
+	for feature := range lookup.features {
+		if !isJoiningFeature(feature) {
+			return false
+		}
+	}
+	return len(lookup.features) > 0
+}
+
+// lookups finds the lookups for the given features of a script, falling back to the default script and
+// then to Latin when the font has nothing for the script. Lookups are listed in the order the font wants
+// them applied.
+func (substitution *glyphSubstitution) lookups(script string, features map[string]bool) []featureLookup {
+	scripts := subtable(substitution.gsub, int(readUint16(substitution.gsub, 4)))
+	var langSys []byte
+	for _, tag := range []string{script, "DFLT", "latn"} {
+		for i := 0; i < int(readUint16(scripts, 0)) && langSys == nil; i++ {
+			record := 2 + 6*i
+			if record+6 <= len(scripts) && string(scripts[record:record+4]) == tag {
+				table := subtable(scripts, int(readUint16(scripts, record+4)))
+				langSys = subtable(table, int(readUint16(table, 0)))
+			}
+		}
+		if langSys != nil {
+			break
+		}
+	}
+
+	featureList := subtable(substitution.gsub, int(readUint16(substitution.gsub, 6)))
+	wanted := map[int]map[string]bool{}
+	for i := 0; i < int(readUint16(langSys, 4)); i++ {
+		record := 2 + 6*int(readUint16(langSys, 6+2*i))
+		if record+6 > len(featureList) || !features[string(featureList[record:record+4])] {
+			continue
+		}
+		table := subtable(featureList, int(readUint16(featureList, record+4)))
+		for j := 0; j < int(readUint16(table, 2)); j++ {
+			index := int(readUint16(table, 4+2*j))
+			if wanted[index] == nil {
+				wanted[index] = map[string]bool{}
+			}
+			wanted[index][string(featureList[record:record+4])] = true
+		}
+	}
+	var lookups []featureLookup
+	lookupList := subtable(substitution.gsub, int(readUint16(substitution.gsub, 8)))
+	for i := 0; i < int(readUint16(lookupList, 0)); i++ {
+		if tags, ok := wanted[i]; ok {
+			lookups = append(lookups, featureLookup{i, tags})
+		}
+	}
+	return lookups
+}
+
+// apply runs a lookup over glyphs. Lookups used only for joining forms start only at glyphs that take one
+// of those forms.
+func (substitution *glyphSubstitution) apply(lookup featureLookup, glyphs []substitutedGlyph) []substitutedGlyph {
+	lookupList := subtable(substitution.gsub, int(readUint16(substitution.gsub, 8)))
+	table := subtable(lookupList, int(readUint16(lookupList, 2+2*lookup.index)))
+	lookupType, flag := readUint16(table, 0), readUint16(table, 2)
+	type typedSubtable struct {
+		lookupType uint16
+		table      []byte
+	}
+	var subtables []typedSubtable
+	for i := 0; i < int(readUint16(table, 4)); i++ {
+		sub := typedSubtable{lookupType, subtable(table, int(readUint16(table, 6+2*i)))}
+		if sub.lookupType == 7 {
+			// extension subtables point to a subtable of another type that is too far away for a 16 bit offset
+			sub.lookupType = readUint16(sub.table, 2)
+			sub.table = subtable(sub.table, int(readUint32(sub.table, 4)))
+		}
+		subtables = append(subtables, sub)
+	}
+
+	for i := 0; i < len(glyphs); i++ {
+		if substitution.ignored(glyphs[i].index, flag) || (lookup.joiningOnly() && !lookup.features[glyphs[i].feature]) {
+			continue
+		}
+		for _, sub := range subtables {
+			var ok bool
+			switch sub.lookupType {
+			case 1:
+				ok = substitution.applySingle(sub.table, &glyphs[i])
+			case 4:
+				glyphs, ok = substitution.applyLigature(sub.table, flag, glyphs, i)
+			}
+			if ok {
+				break
+			}
+		}
+	}
+	return glyphs
+}
+
+// applySingle replaces a glyph with another glyph if the subtable covers it
+func (substitution *glyphSubstitution) applySingle(table []byte, glyph *substitutedGlyph) bool {
+	coverage := coverageIndex(subtable(table, int(readUint16(table, 2))), glyph.index)
+	if coverage < 0 {
+		return false
+	}
+	switch readUint16(table, 0) {
+	case 1:
+		glyph.index = truetype.Index(uint16(glyph.index) + readUint16(table, 4))
+		return true
+	case 2:
+		if coverage < int(readUint16(table, 4)) {
+			glyph.index = truetype.Index(readUint16(table, 6+2*coverage))
+			return true
+		}
+	}
+	return false
+}
+
+// applyLigature replaces the glyph at start and the glyphs after it with a single ligature glyph if the
+// subtable has a ligature for them. Glyphs skipped over because of the lookup flags are kept after the
+// ligature.
+func (substitution *glyphSubstitution) applyLigature(table []byte, flag uint16, glyphs []substitutedGlyph, start int) ([]substitutedGlyph, bool) {
+	coverage := coverageIndex(subtable(table, int(readUint16(table, 2))), glyphs[start].index)
+	if coverage < 0 || coverage >= int(readUint16(table, 4)) {
+		return glyphs, false
+	}
+	set := subtable(table, int(readUint16(table, 6+2*coverage)))
+	for i := 0; i < int(readUint16(set, 0)); i++ {
+		ligature := subtable(set, int(readUint16(set, 2+2*i)))
+		count := int(readUint16(ligature, 2))
+		if count == 0 {
+			continue
+		}
+		matched := []int{start}
+		next := start + 1
+		for c := 1; c < count; c++ {
+			for next < len(glyphs) && substitution.ignored(glyphs[next].index, flag) {
+				next++
+			}
+			if next >= len(glyphs) || glyphs[next].index != truetype.Index(readUint16(ligature, 4+2*(c-1))) {
+				break
+			}
+			matched = append(matched, next)
+			next++
+		}
+		if len(matched) != count {
+			continue
+		}
+
+		result := substitutedGlyph{index: truetype.Index(readUint16(ligature, 0))}
+		var skipped []substitutedGlyph
+		m := 0
+		for g := start; g < next; g++ {
+			if m < len(matched) && matched[m] == g {
+				result.components = append(result.components, glyphs[g].components...)
+				m++
+			} else {
+				skipped = append(skipped, glyphs[g])
+			}
+		}
+		replaced := append([]substitutedGlyph{}, glyphs[:start]...)
+		replaced = append(replaced, result)
+		replaced = append(replaced, skipped...)
+		return append(replaced, glyphs[next:]...), true
+	}
+	return glyphs, false
+}
+
+// ignored determines whether lookups with the given flags skip over a glyph
+func (substitution *glyphSubstitution) ignored(index truetype.Index, flag uint16) bool {
+	switch substitution.class(index) {
+	case glyphClassBase:
+		return flag&lookupIgnoreBaseGlyphs != 0
+	case glyphClassLigature:
+		return flag&lookupIgnoreLigatures != 0
+	case glyphClassMark:
+		return flag&lookupIgnoreMarks != 0
+	}
+	return false
+}
+
+// class gets the class of a glyph from the font's GDEF table, or 0 if it doesn't have one
+func (substitution *glyphSubstitution) class(index truetype.Index) int {
+	table := substitution.classes
+	switch readUint16(table, 0) {
+	case 1:
+		start := truetype.Index(readUint16(table, 2))
+		if index >= start && int(index-start) < int(readUint16(table, 4)) {
+			return int(readUint16(table, 6+2*int(index-start)))
+		}
+	case 2:
+		for i := 0; i < int(readUint16(table, 2)); i++ {
+			record := 4 + 6*i
+			if index >= truetype.Index(readUint16(table, record)) && index <= truetype.Index(readUint16(table, record+2)) {
+				return int(readUint16(table, record+4))
+			}
+		}
+	}
+	return 0
+}
+
+// coverageIndex finds where a glyph is in a coverage table, or -1 if the table doesn't cover it
+func coverageIndex(table []byte, index truetype.Index) int {
+	switch readUint16(table, 0) {
+	case 1:
+		// the glyphs are sorted, so search for it
+		low, high := 0, int(readUint16(table, 2))
+		for low < high {
+			middle := (low + high) / 2
+			glyph := truetype.Index(readUint16(table, 4+2*middle))
+			switch {
+			case glyph == index:
+				return middle
+			case glyph < index:
+				low = middle + 1
+			default:
+				high = middle
+			}
+		}
+	case 2:
+		for i := 0; i < int(readUint16(table, 2)); i++ {
+			record := 4 + 6*i
+			start, end := truetype.Index(readUint16(table, record)), truetype.Index(readUint16(table, record+2))
+			if index >= start && index <= end {
+				return int(readUint16(table, record+4)) + int(index-start)
+			}
+		}
+	}
+	return -1
+}
+
+// isJoiningFeature determines whether a feature picks the joining form of Arabic letters
+func isJoiningFeature(feature string) bool {
+	return feature == "isol" || feature == "init" || feature == "medi" || feature == "fina"
+}
+
+// subtable gets the part of a table starting at an offset, or nil if the offset is null or out of range
+func subtable(table []byte, offset int) []byte {
+	if offset <= 0 || offset >= len(table) {
+		return nil
+	}
+	return table[offset:]
+}
+
+// readUint16 reads a big endian 16 bit number from a table, or 0 if it is out of range
+func readUint16(table []byte, offset int) uint16 {
+	if offset < 0 || offset+2 > len(table) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(table[offset:])
+}
+
+// readUint32 reads a big endian 32 bit number from a table, or 0 if it is out of range
+func readUint32(table []byte, offset int) uint32 {
+	if offset < 0 || offset+4 > len(table) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(table[offset:])
+}
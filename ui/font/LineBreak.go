@@ -0,0 +1,169 @@
+package font
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// lineBreakClass is a simplified version of the line breaking classes from Unicode Standard Annex #14
+type lineBreakClass int
+
+const (
+	// classAlphabetic is for letters, symbols and anything else that sticks to its neighbours
+	classAlphabetic lineBreakClass = 0
+	// classMandatory is for characters that always end a line
+	classMandatory lineBreakClass = 1
+	// classSpace is for spaces, which lines can break after
+	classSpace lineBreakClass = 2
+	// classZeroWidthSpace is for invisible break opportunities
+	classZeroWidthSpace lineBreakClass = 3
+	// classGlue is for characters like no-break space that prevent breaks on either side
+	classGlue lineBreakClass = 4
+	// classBreakAfter is for characters like tabs and dashes that lines can break after
+	classBreakAfter lineBreakClass = 5
+	// classHyphen is for hyphen-minus, which lines can break after unless it's a minus sign before a number
+	classHyphen lineBreakClass = 6
+	// classClose is for closing punctuation that must not start a line
+	classClose lineBreakClass = 7
+	// classOpen is for opening punctuation that must not end a line
+	classOpen lineBreakClass = 8
+	// classCombining is for marks that attach to the character before them
+	classCombining lineBreakClass = 9
+	// classIdeographic is for CJK characters, which lines can break before and after
+	classIdeographic lineBreakClass = 10
+	// classNumeric is for digits
+	classNumeric lineBreakClass = 11
+)
+
+// LineBreak is a place where a line of text can end
+type LineBreak struct {
+	// Offset is the byte offset of the first rune of the next line
+	Offset int
+	// Mandatory is true when the line must end here, like after a newline
+	Mandatory bool
+}
+
+// FindLineBreaks finds every place a line can break within the string. The end of the string is not
+// included.
+func FindLineBreaks(str string) []LineBreak {
+	var breaks []LineBreak
+	var prev, base, beforeSpaces lineBreakClass
+	var prevRune rune
+	first := true
+	for offset, char := range str {
+		class := getLineBreakClass(char)
+		if first {
+			first = false
+			prev, base, beforeSpaces, prevRune = class, class, class, char
+			if class == classCombining {
+				base = classAlphabetic
+			}
+			continue
+		}
+
+		if breakBetween(prev, base, beforeSpaces, prevRune, class, char) {
+			breaks = append(breaks, LineBreak{
+				Offset:    offset,
+				Mandatory: prev == classMandatory,
+			})
+		}
+
+		// combining marks take on the class of the character they are attached to
+		if class != classCombining || base == classMandatory || base == classSpace || base == classZeroWidthSpace {
+			base = class
+			if class == classCombining {
+				base = classAlphabetic
+			}
+		}
+		if class != classSpace {
+			beforeSpaces = base
+		}
+		prev, prevRune = class, char
+	}
+	return breaks
+}
+
+// breakBetween decides whether a line can break between two characters. base is the class of the
+// character before the break once combining marks are attached to it, and beforeSpaces is the class of
+// the last character before any run of spaces leading up to the break.
+func breakBetween(prev lineBreakClass, base lineBreakClass, beforeSpaces lineBreakClass, prevRune rune, next lineBreakClass, nextRune rune) bool {
+	switch {
+	case prev == classMandatory:
+		return !(prevRune == '\r' && nextRune == '\n')
+	case next == classMandatory || next == classSpace || next == classZeroWidthSpace:
+		return false
+	case next == classCombining:
+		return false
+	case beforeSpaces == classZeroWidthSpace:
+		return true
+	case base == classGlue || next == classGlue:
+		return false
+	case next == classClose:
+		return false
+	case beforeSpaces == classOpen:
+		return false
+	case prev == classSpace:
+		return true
+	case base == classBreakAfter:
+		return true
+	case base == classHyphen:
+		return next != classNumeric
+	case base == classIdeographic || next == classIdeographic:
+		return true
+	}
+	return false
+}
+
+// getLineBreakClass determines the line breaking class of a rune
+func getLineBreakClass(char rune) lineBreakClass {
+	switch char {
+	case '\n', '\r', '\v', '\f', '\u0085', '\u2028', '\u2029':
+		return classMandatory
+	case ' ':
+		return classSpace
+	case '\u200B':
+		return classZeroWidthSpace
+	case '\u00A0', '\u202F', '\u2011', '\u2007', '\u2060', '\uFEFF':
+		return classGlue
+	case '\t', '|', '\u1680', '\u2010', '\u2012', '\u2013':
+		return classBreakAfter
+	case '-':
+		return classHyphen
+	case '!', '?', ',', '.', ':', ';', '/', '%', '\u2019', '\u201D', '\u00BB', '\u3001', '\u3002', '\uFF01', '\uFF0C', '\uFF0E', '\uFF1A', '\uFF1B', '\uFF1F':
+		return classClose
+	case '\u2018', '\u201C', '\u00AB':
+		return classOpen
+	}
+	switch {
+	case char >= '\u2000' && char <= '\u200A':
+		return classBreakAfter
+	case unicode.Is(unicode.Pe, char):
+		return classClose
+	case unicode.Is(unicode.Ps, char):
+		return classOpen
+	case unicode.In(char, unicode.Mn, unicode.Me, unicode.Mc):
+		return classCombining
+	case unicode.In(char, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return classIdeographic
+	case unicode.IsDigit(char):
+		return classNumeric
+	}
+	return classAlphabetic
+}
+
+// isLineEnd determines whether a rune ends a line without being drawn, so it can be left off the end of a line
+func isLineEnd(char rune) bool {
+	return getLineBreakClass(char) == classMandatory
+}
+
+// trimLineEnd removes the characters that ended a line from the end of the line
+func trimLineEnd(str string) string {
+	for len(str) > 0 {
+		char, size := utf8.DecodeLastRuneInString(str)
+		if !isLineEnd(char) {
+			break
+		}
+		str = str[:len(str)-size]
+	}
+	return str
+}
@@ -0,0 +1,171 @@
+package font
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TextStyle describes how a run of rich text looks
+type TextStyle struct {
+	// Family, Style and Size in points choose the font the text is drawn with from a FontManager
+	Family string
+	Style  FontStyle
+	Size   float64
+	Color  [4]float32
+	// Underline and Strikethrough draw lines under and through the text
+	Underline     bool
+	Strikethrough bool
+}
+
+// TextRun is a piece of rich text drawn in a single style
+type TextRun struct {
+	Text  string
+	Style TextStyle
+	// Image is the name of an image drawn inline instead of text, or empty for a run of text
+	Image string
+}
+
+// markupTag is a tag that has been opened but not closed yet, along with the style from before it
+type markupTag struct {
+	name  string
+	style TextStyle
+}
+
+// ParseMarkup splits text containing markup tags into runs of text that each have a single style, starting
+// from the given style. Tags look like [b]bold[/b] and can be nested, but must be closed in the reverse of
+// the order they were opened in. Tags still open at the end of the text are closed automatically.
+//
+//	[b]bold[/b]                   uses the bold face of the font family
+//	[i]italic[/i]                 uses the italic face of the font family
+//	[u]underlined[/u]             draws a line under the text
+//	[s]struck out[/s]             draws a line through the text
+//	[color=#ff8000]orange[/color] changes the color, given as #rrggbb or #rrggbbaa
+//	[size=18]large[/size]         changes the size in points
+//	[font=Go]text[/font]          changes the font family
+//	[img=name]                    draws a named image inline, and has no closing tag
+//	[[                            is a literal [
+func ParseMarkup(markup string, style TextStyle) ([]TextRun, error) {
+	var runs []TextRun
+	var tags []markupTag
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			runs = append(runs, TextRun{Text: text.String(), Style: style})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(markup); {
+		if markup[i] != '[' {
+			next := strings.IndexByte(markup[i:], '[')
+			if next < 0 {
+				next = len(markup) - i
+			}
+			text.WriteString(markup[i : i+next])
+			i += next
+			continue
+		}
+		if strings.HasPrefix(markup[i:], "[[") {
+			text.WriteByte('[')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(markup[i:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("Unterminated markup tag at offset %d", i)
+		}
+		tag := markup[i+1 : i+end]
+		i += end + 1
+
+		if strings.HasPrefix(tag, "/") {
+			name := tag[1:]
+			if len(tags) == 0 || tags[len(tags)-1].name != name {
+				return nil, fmt.Errorf("Unexpected closing tag [/%s]", name)
+			}
+			flush()
+			style = tags[len(tags)-1].style
+			tags = tags[:len(tags)-1]
+			continue
+		}
+
+		name, value := tag, ""
+		if equals := strings.IndexByte(tag, '='); equals >= 0 {
+			name, value = tag[:equals], tag[equals+1:]
+		}
+		if name == "img" {
+			if value == "" {
+				return nil, errors.New("Image tag has no image name")
+			}
+			flush()
+			runs = append(runs, TextRun{Style: style, Image: value})
+			continue
+		}
+		next, err := applyTag(style, name, value)
+		if err != nil {
+			return nil, err
+		}
+		flush()
+		tags = append(tags, markupTag{name, style})
+		style = next
+	}
+	flush()
+	return runs, nil
+}
+
+// EscapeMarkup escapes text so that ParseMarkup treats it as plain text, such as before putting a chat
+// message from another player into markup
+func EscapeMarkup(str string) string {
+	return strings.Replace(str, "[", "[[", -1)
+}
+
+// applyTag changes a style as described by an opening markup tag
+func applyTag(style TextStyle, name string, value string) (TextStyle, error) {
+	switch name {
+	case "b":
+		// the styles combine like flags, so bold italic is both bold and italic
+		style.Style |= Bold
+	case "i":
+		style.Style |= Italic
+	case "u":
+		style.Underline = true
+	case "s":
+		style.Strikethrough = true
+	case "color":
+		color, err := parseColor(value)
+		if err != nil {
+			return style, err
+		}
+		style.Color = color
+	case "size":
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil || size <= 0 {
+			return style, fmt.Errorf("Invalid text size %q", value)
+		}
+		style.Size = size
+	case "font":
+		if value == "" {
+			return style, errors.New("Font tag has no font family")
+		}
+		style.Family = value
+	default:
+		return style, fmt.Errorf("Unknown markup tag [%s]", name)
+	}
+	return style, nil
+}
+
+// parseColor parses a color written as #rrggbb or #rrggbbaa
+func parseColor(value string) ([4]float32, error) {
+	if !strings.HasPrefix(value, "#") || (len(value) != 7 && len(value) != 9) {
+		return [4]float32{}, fmt.Errorf("Invalid color %q", value)
+	}
+	color := [4]float32{1, 1, 1, 1}
+	for i := 0; i*2+1 < len(value); i++ {
+		channel, err := strconv.ParseUint(value[i*2+1:i*2+3], 16, 8)
+		if err != nil {
+			return [4]float32{}, fmt.Errorf("Invalid color %q", value)
+		}
+		color[i] = float32(channel) / 255
+	}
+	return color, nil
+}
@@ -0,0 +1,68 @@
+package font
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	base := TextStyle{Family: "Go", Size: 12, Color: [4]float32{1, 1, 1, 1}}
+	bold := base
+	bold.Style = Bold
+	boldItalic := base
+	boldItalic.Style = BoldItalic
+	red := base
+	red.Color = [4]float32{1, 0, 0, 1}
+	underlined := red
+	underlined.Underline = true
+	struck := underlined
+	struck.Strikethrough = true
+	large := base
+	large.Size = 18
+	large.Family = "Other"
+
+	for _, test := range []struct {
+		markup   string
+		expected []TextRun
+	}{
+		{"plain", []TextRun{{Text: "plain", Style: base}}},
+		{"a [b]bold [i]both[/i][/b] b", []TextRun{{Text: "a ", Style: base}, {Text: "bold ", Style: bold}, {Text: "both", Style: boldItalic}, {Text: " b", Style: base}}},
+		{"[color=#ff0000]red [u]line[/u][/color]", []TextRun{{Text: "red ", Style: red}, {Text: "line", Style: underlined}}},
+		{"[color=#ff0000][u]line [s]out", []TextRun{{Text: "line ", Style: underlined}, {Text: "out", Style: struck}}},
+		{"[size=18][font=Other]big", []TextRun{{Text: "big", Style: large}}},
+		{"100 [img=gold]", []TextRun{{Text: "100 ", Style: base}, {Style: base, Image: "gold"}}},
+		{"[[b] is literal", []TextRun{{Text: "[b] is literal", Style: base}}},
+		{"", nil},
+	} {
+		runs, err := ParseMarkup(test.markup, base)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %q: %s", test.markup, err)
+			continue
+		}
+		if !reflect.DeepEqual(runs, test.expected) {
+			t.Errorf("Invalid runs for %q: expected %v, got %v", test.markup, test.expected, runs)
+		}
+	}
+
+	for _, markup := range []string{"[b]bold[/i]", "text[/b]", "[blink]", "[color=red]", "[size=-1]", "[img=]", "[b"} {
+		if _, err := ParseMarkup(markup, base); err == nil {
+			t.Errorf("Expected an error parsing %q", markup)
+		}
+	}
+
+	if runs, _ := ParseMarkup(EscapeMarkup("[b]not bold"), base); len(runs) != 1 || runs[0].Text != "[b]not bold" {
+		t.Errorf("Expected escaped markup to be plain text, got %v", runs)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	if color, err := parseColor("#ff800080"); err != nil || color != [4]float32{1, 128.0 / 255, 0, 128.0 / 255} {
+		t.Errorf("Invalid color %v: %v", color, err)
+	}
+	if color, err := parseColor("#000000"); err != nil || color != [4]float32{0, 0, 0, 1} {
+		t.Errorf("Expected colors without alpha to be opaque, got %v: %v", color, err)
+	}
+	if _, err := parseColor("#00000g"); err == nil {
+		t.Error("Expected an error for an invalid color")
+	}
+}
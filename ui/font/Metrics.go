@@ -0,0 +1,108 @@
+package font
+
+import (
+	"math"
+)
+
+// Bounds represents a rectangle relative to the start of a string's baseline, with y pointing down
+type Bounds struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// GetAdvance determines how far the pen moves after drawing the string, including kerning
+func (font *LoadedFont) GetAdvance(str string) float64 {
+	_, advance := PositionGlyphs(font, str)
+	return advance
+}
+
+// GetAscent determines how far the tallest glyphs in the font reach above the baseline
+func (font *LoadedFont) GetAscent() float64 {
+	return toPixels(font.metrics.Ascent)
+}
+
+// GetDescent determines how far the lowest glyphs in the font reach below the baseline
+func (font *LoadedFont) GetDescent() float64 {
+	return toPixels(font.metrics.Descent)
+}
+
+// GetLineHeight determines the distance between the baselines of two consecutive lines of text, which is
+// the ascent and descent along with the gap the font wants between lines
+func (font *LoadedFont) GetLineHeight() float64 {
+	return toPixels(font.metrics.Height)
+}
+
+// GetInkBounds determines the smallest rectangle that contains every part of every glyph in the string.
+// Strings that don't draw anything, like spaces, have empty bounds at the origin.
+func (font *LoadedFont) GetInkBounds(str string) Bounds {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, contour := range GetContoursForString(font, str, 0, 0) {
+		for _, point := range contour {
+			minX = math.Min(minX, point.X)
+			minY = math.Min(minY, point.Y)
+			maxX = math.Max(maxX, point.X)
+			maxY = math.Max(maxY, point.Y)
+		}
+	}
+	if minX > maxX {
+		return Bounds{}
+	}
+	return Bounds{
+		X:      minX,
+		Y:      minY,
+		Width:  maxX - minX,
+		Height: maxY - minY,
+	}
+}
+
+// GetUnderline determines how far below the baseline the top of an underline is drawn and how thick it is,
+// both rounded to whole pixels so that underlines stay sharp. Fonts without underline metrics get an
+// underline a third of the way down their descent.
+func (font *LoadedFont) GetUnderline() (float64, float64) {
+	metrics := font.Font.decorations
+	if metrics.unitsPerEm == 0 || metrics.underlineThickness <= 0 {
+		thickness := math.Max(1, math.Round(toPixels(font.Size)/16))
+		return math.Max(1, math.Round(font.GetDescent()/3)), thickness
+	}
+	scale := toPixels(font.Size) / float64(metrics.unitsPerEm)
+	return math.Max(1, math.Round(-float64(metrics.underlinePosition)*scale)), math.Max(1, math.Round(float64(metrics.underlineThickness)*scale))
+}
+
+// GetStrikethrough determines how far below the baseline the top of a strikethrough is drawn, which is
+// negative since it is drawn above the baseline, and how thick it is. Both are rounded to whole pixels.
+// Fonts without strikethrough metrics get one a quarter of an em above the baseline.
+func (font *LoadedFont) GetStrikethrough() (float64, float64) {
+	metrics := font.Font.decorations
+	if metrics.unitsPerEm == 0 || metrics.strikeoutSize <= 0 {
+		thickness := math.Max(1, math.Round(toPixels(font.Size)/16))
+		return -math.Round(toPixels(font.Size)/4 + thickness/2), thickness
+	}
+	scale := toPixels(font.Size) / float64(metrics.unitsPerEm)
+	return -math.Round(float64(metrics.strikeoutPosition) * scale), math.Max(1, math.Round(float64(metrics.strikeoutSize)*scale))
+}
+
+// decorationMetrics are where a font wants underlines and strikethroughs drawn, in font units with y
+// pointing up. The positions are of the top of each line.
+type decorationMetrics struct {
+	unitsPerEm         int
+	underlinePosition  int
+	underlineThickness int
+	strikeoutPosition  int
+	strikeoutSize      int
+}
+
+// parseDecorationMetrics reads the decoration metrics from a font's head, post and OS/2 tables, leaving
+// them zero when the font doesn't have the tables
+func parseDecorationMetrics(data []byte) decorationMetrics {
+	post, os2 := findTable(data, "post"), findTable(data, "OS/2")
+	return decorationMetrics{
+		unitsPerEm:         int(readUint16(findTable(data, "head"), 18)),
+		underlinePosition:  int(int16(readUint16(post, 8))),
+		underlineThickness: int(int16(readUint16(post, 10))),
+		strikeoutPosition:  int(int16(readUint16(os2, 28))),
+		strikeoutSize:      int(int16(readUint16(os2, 26))),
+	}
+}
@@ -0,0 +1,81 @@
+package font
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/image/math/fixed"
+)
+
+func TestMetrics(t *testing.T) {
+	fnt := LoadTestFont(t, 32)
+	if fnt.GetAscent() <= 0 || fnt.GetDescent() <= 0 {
+		t.Errorf("Expected a positive ascent and descent, got %f and %f", fnt.GetAscent(), fnt.GetDescent())
+	}
+	if fnt.GetLineHeight() < 32 || fnt.GetLineHeight() > 64 {
+		t.Errorf("Invalid line height %f for a 32 pixel font", fnt.GetLineHeight())
+	}
+
+	a := fnt.GetGlyph(fnt.Font.Index('A')).AdvanceWidth
+	v := fnt.GetGlyph(fnt.Font.Index('V')).AdvanceWidth
+	kern := GetKernsForIndices(fnt, GetIndicesForString(fnt, "AV"))[0]
+	if advance := fnt.GetAdvance("AV"); math.Abs(advance-(a+v+kern)) > 1e-9 {
+		t.Errorf("Invalid advance: expected %f, got %f", a+v+kern, advance)
+	}
+	if fnt.GetAdvance("") != 0 {
+		t.Error("Expected an empty string to have no advance")
+	}
+
+	bounds := fnt.GetInkBounds("Hg")
+	if bounds.Y >= 0 || bounds.Y+bounds.Height <= 0 {
+		t.Errorf("Expected the ink to cross the baseline, got %v", bounds)
+	}
+	if -bounds.Y > fnt.GetAscent() || bounds.Y+bounds.Height > fnt.GetDescent() {
+		t.Errorf("Expected the ink to fit between the ascent and descent, got %v", bounds)
+	}
+	if bounds.X < 0 || bounds.X+bounds.Width > fnt.GetAdvance("Hg") {
+		t.Errorf("Expected the ink to fit within the advance, got %v", bounds)
+	}
+	if empty := fnt.GetInkBounds(" "); empty != (Bounds{}) {
+		t.Errorf("Expected a space to have no ink, got %v", empty)
+	}
+
+	// the line gap from the hhea table goes between lines
+	for _, file := range []string{"testdata/glyfTest.ttf", "testdata/CFFTest.otf"} {
+		fnt, err := LoadFont(file, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gap := fnt.GetLineHeight() - fnt.GetAscent() - fnt.GetDescent()
+		if gap <= 0 || gap > 8 {
+			t.Errorf("Expected %s to have a line gap of a few pixels, got %f", file, gap)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	if pixels := PointsToPixels(12, 96); pixels != 16 {
+		t.Errorf("Expected 12 points at 96 DPI to be 16 pixels, got %f", pixels)
+	}
+	small := LoadTestFont(t, 16)
+	large := LoadTestFont(t, 32)
+	// measure the advance in font units, where the em square is the font's units per em wide
+	units := large.Font.TrueType.FUnitsPerEm()
+	width := large.Font.TrueType.HMetric(fixed.Int26_6(units), large.Font.Index('H')).AdvanceWidth
+	if advance := large.GetAdvance("H"); math.Abs(advance-32*float64(width)/float64(units)) > 1.0/64 {
+		t.Errorf("Invalid advance %f for a 32 pixel font", advance)
+	}
+	for _, test := range []struct {
+		name         string
+		small, large float64
+	}{
+		{"advance", small.GetAdvance("AVH"), large.GetAdvance("AVH")},
+		{"ascent", small.GetAscent(), large.GetAscent()},
+		{"descent", small.GetDescent(), large.GetDescent()},
+		{"width", small.GetInkBounds("o").Width, large.GetInkBounds("o").Width},
+	} {
+		if math.Abs(test.large-2*test.small) > 2.0/64 {
+			t.Errorf("Expected the %s to double with the size, got %f and %f", test.name, test.small, test.large)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package font
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// objectReplacement stands in for inline images in the text of a rich text layout
+const objectReplacement = "\ufffc"
+
+// RichTextLayout is a block of text made of runs in different styles, broken into lines
+type RichTextLayout struct {
+	// Text is the text of every run joined together, with each inline image replaced by U+FFFC
+	Text   string
+	Lines  []RichTextLine
+	Width  float64
+	Height float64
+}
+
+// RichTextLine is a single line of laid out rich text
+type RichTextLine struct {
+	// Start and End are the byte offsets of the line in the layout's text, not including the newline that ended it
+	Start int
+	End   int
+	// Pieces are the parts of each run that are on the line, in order
+	Pieces []RichTextPiece
+	// X is where the line starts once it has been aligned
+	X float64
+	// Baseline is the distance from the top of the layout to the baseline of the line
+	Baseline float64
+	// Width is the width of the line, not including trailing spaces
+	Width float64
+}
+
+// RichTextPiece is the part of a run that is on one line
+type RichTextPiece struct {
+	Style TextStyle
+	// Font is the font the glyphs are drawn with, which is also used to size inline images
+	Font *LoadedFont
+	// Glyphs are positioned relative to the top left corner of the layout
+	Glyphs []PositionedGlyph
+	// Image is drawn instead of glyphs when the piece is an inline image
+	Image image.Image
+	// Bounds is the area covered by the piece relative to the top left corner of the layout, from the top
+	// of the font's ascent to the bottom of its descent, or the area to draw the image in
+	Bounds Bounds
+}
+
+// richSpan is a run along with where it is in the layout's text and what it needs to be measured
+type richSpan struct {
+	start, end int
+	style      TextStyle
+	font       *LoadedFont
+	image      image.Image
+	width      float64
+	height     float64
+}
+
+// LayoutRichText lays out runs of rich text, like those from ParseMarkup, getting the font for each run
+// from a font manager and each inline image from images by name. Runs whose font family doesn't have their
+// style fall back to the regular face. Inline images are scaled to the ascent of the run's font, keeping
+// their aspect ratio, and sit on the baseline. Lines are as tall as the tallest run or image on them.
+func LayoutRichText(manager *FontManager, runs []TextRun, images map[string]image.Image, options LayoutOptions) (*RichTextLayout, error) {
+	layout := &RichTextLayout{}
+	lineSpacing := options.LineSpacing
+	if lineSpacing == 0 {
+		lineSpacing = 1
+	}
+
+	// join the runs together so lines can break anywhere, even between runs
+	var text strings.Builder
+	var spans []richSpan
+	for _, run := range runs {
+		fnt, err := manager.GetFont(run.Style.Family, run.Style.Style, run.Style.Size)
+		if err != nil && run.Style.Style != Regular {
+			fnt, err = manager.GetFont(run.Style.Family, Regular, run.Style.Size)
+		}
+		if err != nil {
+			return nil, err
+		}
+		span := richSpan{start: text.Len(), style: run.Style, font: fnt}
+		if run.Image != "" {
+			img, ok := images[run.Image]
+			if !ok {
+				return nil, fmt.Errorf("No image named %q", run.Image)
+			}
+			size := img.Bounds().Size()
+			span.image = img
+			span.height = fnt.GetAscent()
+			if size.Y > 0 {
+				span.width = span.height * float64(size.X) / float64(size.Y)
+			}
+			text.WriteString(objectReplacement)
+		} else {
+			text.WriteString(run.Text)
+		}
+		span.end = text.Len()
+		spans = append(spans, span)
+	}
+	layout.Text = text.String()
+	str := layout.Text
+	if len(spans) == 0 {
+		return layout, nil
+	}
+
+	// measure the text between two offsets from the advances of each run, shaped once
+	advances := make([]float64, len(str)+1)
+	for _, span := range spans {
+		if span.image != nil {
+			advances[span.start+1] += span.width
+			continue
+		}
+		table := advanceTable(span.font, str[span.start:span.end])
+		for i := 1; i < len(table); i++ {
+			advances[span.start+i] += table[i] - table[i-1]
+		}
+	}
+	for i := 1; i < len(advances); i++ {
+		advances[i] += advances[i-1]
+	}
+	measure := func(start, end int) float64 {
+		end = start + len(strings.TrimRight(trimLineEnd(str[start:end]), " \t"))
+		return advances[end] - advances[start]
+	}
+
+	// position the pieces of each line
+	baseline, descent := 0.0, 0.0
+	lines := breakLines(str, options.MaxWidth, measure)
+	for i, s := range lines {
+		line := RichTextLine{
+			Start: s.start,
+			End:   s.start + len(trimLineEnd(str[s.start:s.end])),
+			Width: measure(s.start, s.end),
+		}
+		lineAscent, lineDescent := 0.0, 0.0
+		x := 0.0
+		for _, span := range spans {
+			from, to := maxInt(span.start, line.Start), minInt(span.end, line.End)
+			if from >= to {
+				continue
+			}
+			piece := RichTextPiece{Style: span.style, Font: span.font}
+			if span.image != nil {
+				piece.Image = span.image
+				piece.Bounds = Bounds{X: x, Y: -span.height, Width: span.width, Height: span.height}
+				lineAscent = math.Max(lineAscent, span.height)
+			} else {
+				glyphs, advance := PositionGlyphs(span.font, str[from:to])
+				for g := range glyphs {
+					glyphs[g].X += x
+					glyphs[g].Offset += from
+				}
+				piece.Glyphs = glyphs
+				piece.Bounds = Bounds{X: x, Y: -span.font.GetAscent(), Width: advance, Height: span.font.GetLineHeight()}
+				lineAscent = math.Max(lineAscent, span.font.GetAscent())
+				lineDescent = math.Max(lineDescent, span.font.GetDescent())
+			}
+			x += piece.Bounds.Width
+			line.Pieces = append(line.Pieces, piece)
+		}
+		if len(line.Pieces) == 0 {
+			// empty lines are as tall as the text that was before them
+			fnt := spans[0].font
+			for _, span := range spans {
+				if span.start <= line.Start {
+					fnt = span.font
+				}
+			}
+			lineAscent, lineDescent = fnt.GetAscent(), fnt.GetDescent()
+		}
+		if i == 0 {
+			baseline = lineAscent
+		} else {
+			baseline += (descent + lineAscent) * lineSpacing
+		}
+		descent = lineDescent
+		line.Baseline = baseline
+		for p := range line.Pieces {
+			piece := &line.Pieces[p]
+			piece.Bounds.Y += baseline
+			// trailing spaces aren't part of the line
+			piece.Bounds.Width = math.Max(0, math.Min(piece.Bounds.Width, line.Width-piece.Bounds.X))
+			for g := range piece.Glyphs {
+				piece.Glyphs[g].Y = baseline
+			}
+		}
+		layout.Lines = append(layout.Lines, line)
+		layout.Width = math.Max(layout.Width, line.Width)
+	}
+	layout.Height = baseline + descent
+
+	// align each line within the maximum width, or within the widest line if there isn't one
+	width := layout.Width
+	if options.MaxWidth > 0 {
+		width = options.MaxWidth
+	}
+	for i := range layout.Lines {
+		line := &layout.Lines[i]
+		switch options.Alignment {
+		case AlignCenter:
+			line.X = (width - line.Width) / 2
+		case AlignRight:
+			line.X = width - line.Width
+		case AlignJustify:
+			if !lines[i].paragraph {
+				justifyRichLine(str, line, width)
+			}
+		}
+		for p := range line.Pieces {
+			piece := &line.Pieces[p]
+			piece.Bounds.X += line.X
+			for g := range piece.Glyphs {
+				piece.Glyphs[g].X += line.X
+			}
+		}
+	}
+	return layout, nil
+}
+
+// justifyRichLine spreads the extra space at the end of a line of rich text between the spaces inside it
+func justifyRichLine(str string, line *RichTextLine, width float64) {
+	spaces := 0
+	for _, piece := range line.Pieces {
+		for _, glyph := range piece.Glyphs {
+			if glyph.X < line.Width && str[glyph.Offset] == ' ' {
+				spaces++
+			}
+		}
+	}
+	if spaces == 0 {
+		return
+	}
+	extra := (width - line.Width) / float64(spaces)
+	shift := 0.0
+	for p := range line.Pieces {
+		piece := &line.Pieces[p]
+		piece.Bounds.X += shift
+		start := shift
+		for g := range piece.Glyphs {
+			piece.Glyphs[g].X += shift
+			if piece.Glyphs[g].X-shift < line.Width && str[piece.Glyphs[g].Offset] == ' ' {
+				shift += extra
+			}
+		}
+		piece.Bounds.Width += shift - start
+	}
+	line.Width = width
+}
+
+// minInt finds the smaller of two integers
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxInt finds the larger of two integers
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
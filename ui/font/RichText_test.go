@@ -0,0 +1,106 @@
+package font
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestLayoutRichText(t *testing.T) {
+	manager := LoadTestManager(t)
+	base := TextStyle{Family: "Go", Size: 16, Color: [4]float32{1, 1, 1, 1}}
+	runs, err := ParseMarkup("Hi [b]there[/b] [size=32]big[/size] [i]slanted[/i]", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := LayoutRichText(manager, runs, nil, LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layout.Lines) != 1 || len(layout.Lines[0].Pieces) != 6 {
+		t.Fatalf("Expected one line with a piece for each run, got %v", layout.Lines)
+	}
+	regular, _ := manager.GetFont("Go", Regular, 16)
+	bold, _ := manager.GetFont("Go", Bold, 16)
+	large, _ := manager.GetFont("Go", Regular, 32)
+	pieces := layout.Lines[0].Pieces
+	if pieces[1].Font != bold || pieces[3].Font != large {
+		t.Error("Expected each run to be drawn with the font for its style")
+	}
+	if pieces[5].Font != regular {
+		t.Error("Expected a missing italic face to fall back to the regular face")
+	}
+	// the runs follow each other along a shared baseline tall enough for the largest text
+	if math.Abs(pieces[1].Bounds.X-regular.GetAdvance("Hi ")) > 1e-9 || math.Abs(pieces[2].Bounds.X-pieces[1].Bounds.X-bold.GetAdvance("there")) > 1e-9 {
+		t.Errorf("Invalid piece positions %v and %v", pieces[1].Bounds, pieces[2].Bounds)
+	}
+	if layout.Lines[0].Baseline != large.GetAscent() || pieces[0].Glyphs[0].Y != large.GetAscent() {
+		t.Errorf("Expected the baseline to be at the ascent of the largest text, got %f", layout.Lines[0].Baseline)
+	}
+	if layout.Height != large.GetAscent()+large.GetDescent() {
+		t.Errorf("Invalid height %f", layout.Height)
+	}
+	if pieces[1].Glyphs[0].Offset != len("Hi ") || layout.Text[pieces[3].Glyphs[0].Offset:] != "big slanted" {
+		t.Error("Expected glyph offsets to point into the joined text")
+	}
+}
+
+func TestLayoutRichTextImages(t *testing.T) {
+	manager := LoadTestManager(t)
+	base := TextStyle{Family: "Go", Size: 16}
+	icon := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	runs, _ := ParseMarkup("50 [img=gold] gold", base)
+	layout, err := LayoutRichText(manager, runs, map[string]image.Image{"gold": icon}, LayoutOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fnt, _ := manager.GetFont("Go", Regular, 16)
+	piece := layout.Lines[0].Pieces[1]
+	if piece.Image != icon {
+		t.Fatal("Expected the second piece to be the image")
+	}
+	expected := Bounds{X: fnt.GetAdvance("50 "), Y: 0, Width: fnt.GetAscent() * 2, Height: fnt.GetAscent()}
+	if math.Abs(piece.Bounds.X-expected.X) > 1e-9 || piece.Bounds.Y != expected.Y || piece.Bounds.Width != expected.Width || piece.Bounds.Height != expected.Height {
+		t.Errorf("Invalid image bounds: expected %v, got %v", expected, piece.Bounds)
+	}
+	if layout.Lines[0].Pieces[2].Bounds.X != piece.Bounds.X+piece.Bounds.Width {
+		t.Error("Expected the text after the image to start where it ends")
+	}
+
+	if _, err := LayoutRichText(manager, runs, nil, LayoutOptions{}); err == nil {
+		t.Error("Expected an error for a missing image")
+	}
+}
+
+func TestLayoutRichTextWrapping(t *testing.T) {
+	manager := LoadTestManager(t)
+	base := TextStyle{Family: "Go", Size: 16}
+	runs, _ := ParseMarkup("one [b]two three[/b] four\n\n[size=32]five", base)
+	fnt, _ := manager.GetFont("Go", Regular, 16)
+	layout, err := LayoutRichText(manager, runs, nil, LayoutOptions{MaxWidth: fnt.GetAdvance("one two three")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range layout.Lines {
+		lines = append(lines, layout.Text[line.Start:line.End])
+	}
+	expected := []string{"one two ", "three four", "", "five"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected lines %q, got %q", expected, lines)
+	}
+	for i := range lines {
+		if lines[i] != expected[i] {
+			t.Errorf("Expected lines %q, got %q", expected, lines)
+			break
+		}
+	}
+	// the bold run is split across the first two lines
+	if len(layout.Lines[1].Pieces) != 2 || layout.Lines[1].Pieces[0].Style.Style != Bold || layout.Lines[1].Pieces[0].Bounds.X != 0 {
+		t.Errorf("Expected the second line to start with the rest of the bold run, got %v", layout.Lines[1].Pieces)
+	}
+	large, _ := manager.GetFont("Go", Regular, 32)
+	if gap := layout.Lines[3].Baseline - layout.Lines[2].Baseline; math.Abs(gap-fnt.GetDescent()-large.GetAscent()) > 1e-9 {
+		t.Errorf("Expected the gap before the large line to fit its ascent, got %f", gap)
+	}
+}
@@ -0,0 +1,448 @@
+package font
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// shapingFeatures are the GSUB features applied when shaping text
+var shapingFeatures = map[string]bool{
+	"ccmp": true,
+	"isol": true,
+	"init": true,
+	"medi": true,
+	"fina": true,
+	"rlig": true,
+	"liga": true,
+	"clig": true,
+}
+
+// rightJoining are the Arabic letters that only join to the letter before them
+var rightJoining = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0622, 0x0625, 1}, {0x0627, 0x0627, 1}, {0x0629, 0x0629, 1}, {0x062f, 0x0632, 1},
+		{0x0648, 0x0648, 1}, {0x0671, 0x0673, 1}, {0x0675, 0x0677, 1}, {0x0688, 0x0699, 1},
+		{0x06c0, 0x06c0, 1}, {0x06c3, 0x06cb, 1}, {0x06cd, 0x06cd, 1}, {0x06cf, 0x06cf, 1},
+		{0x06d2, 0x06d3, 1}, {0x06d5, 0x06d5, 1}, {0x06ee, 0x06ef, 1}, {0x0759, 0x075b, 1},
+		{0x076b, 0x076c, 1}, {0x0771, 0x0771, 1}, {0x0773, 0x0774, 1}, {0x0778, 0x0779, 1},
+	},
+}
+
+// dualJoining are the Arabic letters that join to the letters on both sides of them
+var dualJoining = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0620, 0x0620, 1}, {0x0626, 0x0626, 1}, {0x0628, 0x0628, 1}, {0x062a, 0x062e, 1},
+		{0x0633, 0x063f, 1}, {0x0641, 0x0647, 1}, {0x0649, 0x064a, 1}, {0x066e, 0x066f, 1},
+		{0x0678, 0x0687, 1}, {0x069a, 0x06bf, 1}, {0x06c1, 0x06c2, 1}, {0x06cc, 0x06cc, 1},
+		{0x06ce, 0x06ce, 1}, {0x06d0, 0x06d1, 1}, {0x06fa, 0x06fc, 1}, {0x06ff, 0x06ff, 1},
+		{0x0750, 0x0758, 1}, {0x075c, 0x076a, 1}, {0x076d, 0x0770, 1}, {0x0772, 0x0772, 1},
+		{0x0775, 0x0777, 1}, {0x077a, 0x077f, 1},
+	},
+}
+
+// Caret is a place in a string where the text cursor can be put
+type Caret struct {
+	// Offset is the byte offset in the string of the rune the caret is in front of
+	Offset int
+	// X is where the caret is drawn relative to the start of the text
+	X float64
+}
+
+// ShapedText is a string that has been turned into glyphs ready to be drawn
+type ShapedText struct {
+	// Glyphs are placed along a single baseline starting at the origin, from left to right in the order
+	// they are drawn, which isn't always the order of the runes they came from
+	Glyphs []PositionedGlyph
+	// Advance is how far the pen moved
+	Advance float64
+	// Carets are every place the cursor can be put, in the order of the string from its start to its end.
+	// Combining marks and the letters of ligatures can't be separated from the rune before them.
+	Carets []Caret
+}
+
+// shapingRune is a rune going through shaping
+type shapingRune struct {
+	offset int
+	char   rune
+	level  int
+	// cluster is the index of the rune that starts the cluster of a rune and the combining marks after it
+	cluster int
+	font    *LoadedFont
+	index   truetype.Index
+	form    string
+}
+
+// shapedUnit is a base glyph and the marks attached to it, which are always drawn together
+type shapedUnit struct {
+	font   *LoadedFont
+	level  int
+	glyphs []substitutedGlyph
+	// clusters are the indices of the runes starting each cluster the unit was made from, in order
+	clusters []int
+}
+
+// ShapeText turns a string into glyphs. Runes are reordered for display using the Unicode bidirectional
+// algorithm, combining marks are placed over the rune before them, and Arabic letters are joined and
+// ligatures formed where the font has substitutions for them. The direction of the text comes from its
+// first strong character, so text is shaped as a single paragraph.
+func ShapeText(font *LoadedFont, str string) ShapedText {
+	runes := analyzeRunes(font, str)
+	units := substituteGlyphs(runes)
+	reorderUnits(units)
+
+	shaped := ShapedText{}
+	type clusterEdges struct{ leading, trailing float64 }
+	edges := map[int]clusterEdges{}
+	var last *shapedUnit
+	dx := 0.0
+	for u := range units {
+		unit := &units[u]
+		if last != nil && last.font == unit.font {
+			dx += toPixels(unit.font.Font.Kern(unit.font.Size, last.glyphs[0].index, unit.glyphs[0].index, unit.font.Options.Hinting))
+		}
+		start := dx
+		advance := unit.font.GetGlyph(unit.glyphs[0].index).AdvanceWidth
+		for g, glyph := range unit.glyphs {
+			x := dx
+			if g > 0 {
+				// marks without an advance are drawn back over the base, while marks with one are centered on it
+				markAdvance := unit.font.GetGlyph(glyph.index).AdvanceWidth
+				x = dx + advance
+				if markAdvance != 0 {
+					x = dx + (advance-markAdvance)/2
+				}
+			}
+			shaped.Glyphs = append(shaped.Glyphs, PositionedGlyph{
+				Font:   unit.font,
+				Index:  glyph.index,
+				X:      x,
+				Offset: runes[glyph.components[0]].offset,
+			})
+		}
+		dx += advance
+		last = unit
+
+		// the letters of a ligature share its width
+		width := (dx - start) / float64(len(unit.clusters))
+		for i, cluster := range unit.clusters {
+			if unit.level%2 == 0 {
+				edges[cluster] = clusterEdges{start + float64(i)*width, start + float64(i+1)*width}
+			} else {
+				edges[cluster] = clusterEdges{dx - float64(i)*width, dx - float64(i+1)*width}
+			}
+		}
+	}
+	shaped.Advance = dx
+
+	trailing := 0.0
+	for i, r := range runes {
+		if r.cluster == i {
+			shaped.Carets = append(shaped.Carets, Caret{Offset: r.offset, X: edges[i].leading})
+			trailing = edges[i].trailing
+		}
+	}
+	shaped.Carets = append(shaped.Carets, Caret{Offset: len(str), X: trailing})
+	return shaped
+}
+
+// GetCaretX gets where the caret in front of the rune at a byte offset is drawn. Offsets in the middle of a
+// cluster use the caret at the start of the cluster.
+func (text ShapedText) GetCaretX(offset int) float64 {
+	x := 0.0
+	for _, caret := range text.Carets {
+		if caret.Offset > offset {
+			break
+		}
+		x = caret.X
+	}
+	return x
+}
+
+// GetOffsetAt gets the byte offset of the caret closest to a position along the text, such as where it
+// was clicked
+func (text ShapedText) GetOffsetAt(x float64) int {
+	if len(text.Carets) == 0 {
+		return 0
+	}
+	best := 0
+	for i, caret := range text.Carets {
+		if math.Abs(caret.X-x) < math.Abs(text.Carets[best].X-x) {
+			best = i
+		}
+	}
+	return text.Carets[best].Offset
+}
+
+// analyzeRunes finds the embedding level, cluster, font and glyph of each rune in a string, and the joining
+// form of Arabic letters
+func analyzeRunes(font *LoadedFont, str string) []shapingRune {
+	var runes []shapingRune
+	for offset, char := range str {
+		runes = append(runes, shapingRune{offset: offset, char: char})
+	}
+	levels := bidiLevels(str, len(runes))
+	for i := range runes {
+		r := &runes[i]
+		r.level = levels[i]
+		r.cluster = i
+		if i > 0 && isMark(r.char) {
+			r.cluster = runes[i-1].cluster
+			r.level = runes[i-1].level
+		}
+		char := r.char
+		if r.level%2 == 1 {
+			// brackets in right to left text are drawn facing the other way
+			char = mirrorRune(char)
+		}
+		if r.cluster != i {
+			// keep marks in the same font as the rune they attach to if it has them
+			base := runes[r.cluster].font
+			if index := base.Font.Index(char); index != 0 {
+				r.font, r.index = base, index
+				continue
+			}
+		}
+		r.font, r.index = font.GetFontForRune(char)
+	}
+
+	// pick the joining form of each Arabic letter from the letters around it, skipping over marks
+	for i := range runes {
+		joining := joiningType(runes[i].char)
+		if joining != 'R' && joining != 'D' {
+			continue
+		}
+		prev, next := byte('U'), byte('U')
+		for j := i - 1; j >= 0; j-- {
+			if prev = joiningType(runes[j].char); prev != 'T' {
+				break
+			}
+		}
+		for j := i + 1; j < len(runes); j++ {
+			if next = joiningType(runes[j].char); next != 'T' {
+				break
+			}
+		}
+		joinsBefore := prev == 'D' || prev == 'C'
+		joinsAfter := joining == 'D' && (next == 'R' || next == 'D' || next == 'C')
+		switch {
+		case joinsBefore && joinsAfter:
+			runes[i].form = "medi"
+		case joinsBefore:
+			runes[i].form = "fina"
+		case joinsAfter:
+			runes[i].form = "init"
+		default:
+			runes[i].form = "isol"
+		}
+	}
+	return runes
+}
+
+// substituteGlyphs applies the font's substitutions to runs of runes that share a font and a level, and
+// groups the resulting glyphs into units of a base glyph and its marks
+func substituteGlyphs(runes []shapingRune) []shapedUnit {
+	var units []shapedUnit
+	for start := 0; start < len(runes); {
+		end := start + 1
+		for end < len(runes) && runes[end].font == runes[start].font && runes[end].level == runes[start].level {
+			end++
+		}
+		fnt := runes[start].font
+		glyphs := make([]substitutedGlyph, 0, end-start)
+		script := "DFLT"
+		for i := start; i < end; i++ {
+			glyphs = append(glyphs, substitutedGlyph{index: runes[i].index, components: []int{i}, feature: runes[i].form})
+			if script == "DFLT" {
+				script = scriptTag(runes[i].char)
+			}
+		}
+		if substitution := fnt.Font.substitution; substitution != nil {
+			for _, lookup := range substitution.lookups(script, shapingFeatures) {
+				glyphs = substitution.apply(lookup, glyphs)
+			}
+		}
+
+		for _, glyph := range glyphs {
+			first := glyph.components[0]
+			mark := isMark(runes[first].char) && runes[first].cluster != first
+			if fnt.Font.substitution != nil && fnt.Font.substitution.class(glyph.index) == glyphClassMark {
+				mark = true
+			}
+			if mark && len(units) > 0 && units[len(units)-1].font == fnt {
+				unit := &units[len(units)-1]
+				unit.glyphs = append(unit.glyphs, glyph)
+				unit.clusters = addClusters(unit.clusters, runes, glyph.components)
+				continue
+			}
+			units = append(units, shapedUnit{
+				font:     fnt,
+				level:    runes[first].level,
+				glyphs:   []substitutedGlyph{glyph},
+				clusters: addClusters(nil, runes, glyph.components),
+			})
+		}
+		start = end
+	}
+	return units
+}
+
+// addClusters adds the clusters of the runes a glyph was made from to a sorted list of clusters
+func addClusters(clusters []int, runes []shapingRune, components []int) []int {
+	for _, component := range components {
+		cluster := runes[component].cluster
+		i := 0
+		for i < len(clusters) && clusters[i] < cluster {
+			i++
+		}
+		if i < len(clusters) && clusters[i] == cluster {
+			continue
+		}
+		clusters = append(clusters[:i], append([]int{cluster}, clusters[i:]...)...)
+	}
+	return clusters
+}
+
+// reorderUnits puts units in the order they are displayed by reversing every sequence of units at each
+// embedding level, from the highest level down to the lowest odd level
+func reorderUnits(units []shapedUnit) {
+	highest, lowestOdd := 0, -1
+	for _, unit := range units {
+		if unit.level > highest {
+			highest = unit.level
+		}
+		if unit.level%2 == 1 && (lowestOdd < 0 || unit.level < lowestOdd) {
+			lowestOdd = unit.level
+		}
+	}
+	if lowestOdd < 0 {
+		return
+	}
+	for level := highest; level >= lowestOdd; level-- {
+		for start := 0; start < len(units); start++ {
+			if units[start].level < level {
+				continue
+			}
+			end := start
+			for end < len(units) && units[end].level >= level {
+				end++
+			}
+			for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+				units[i], units[j] = units[j], units[i]
+			}
+			start = end
+		}
+	}
+}
+
+// bidiLevels finds the embedding level of each rune in a string, which is even for left to right text and
+// odd for right to left text. Strings without any right to left text are all at level 0.
+func bidiLevels(str string, count int) []int {
+	levels := make([]int, count)
+	base, rightToLeft := -1, false
+	for _, char := range str {
+		properties, _ := bidi.LookupRune(char)
+		switch properties.Class() {
+		case bidi.L:
+			if base < 0 {
+				base = 0
+			}
+		case bidi.R, bidi.AL:
+			if base < 0 {
+				base = 1
+			}
+			rightToLeft = true
+		case bidi.AN:
+			rightToLeft = true
+		}
+	}
+	if !rightToLeft {
+		return levels
+	}
+	if base < 0 {
+		base = 0
+	}
+	for i := range levels {
+		levels[i] = base
+	}
+
+	var paragraph bidi.Paragraph
+	var err error
+	if base == 1 {
+		_, err = paragraph.SetString(str, bidi.DefaultDirection(bidi.RightToLeft))
+	} else {
+		_, err = paragraph.SetString(str)
+	}
+	if err != nil {
+		return levels
+	}
+	ordering, err := paragraph.Order()
+	if err != nil {
+		return levels
+	}
+	for i := 0; i < ordering.NumRuns(); i++ {
+		run := ordering.Run(i)
+		level := base
+		if run.Direction() == bidi.RightToLeft && base == 0 {
+			level = 1
+		} else if run.Direction() == bidi.LeftToRight && base == 1 {
+			level = 2
+		}
+		start, end := run.Pos()
+		for j := start; j <= end && j < count; j++ {
+			levels[j] = level
+		}
+	}
+	return levels
+}
+
+// mirrorRune gets the bracket facing the other way for brackets, or the rune itself for anything else
+func mirrorRune(char rune) rune {
+	if properties, _ := bidi.LookupRune(char); !properties.IsBracket() {
+		return char
+	}
+	mirrored, _ := utf8.DecodeRuneInString(bidi.ReverseString(string(char)))
+	return mirrored
+}
+
+// isMark determines whether a rune is a combining mark that attaches to the rune before it
+func isMark(char rune) bool {
+	return unicode.In(char, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+// joiningType gets how a rune joins to the Arabic letters around it: 'D' for letters that join on both
+// sides, 'R' for letters that only join to the letter before them, 'C' for tatweel and the zero width
+// joiner which letters join to, 'T' for marks which letters join through, and 'U' for everything else
+func joiningType(char rune) byte {
+	switch {
+	case isMark(char):
+		return 'T'
+	case char == 0x0640 || char == 0x200d:
+		return 'C'
+	case unicode.Is(rightJoining, char):
+		return 'R'
+	case unicode.Is(dualJoining, char):
+		return 'D'
+	}
+	return 'U'
+}
+
+// scriptTag gets the OpenType tag of the script a rune is written in, or DFLT if it doesn't belong to one
+func scriptTag(char rune) string {
+	switch {
+	case unicode.Is(unicode.Arabic, char):
+		return "arab"
+	case unicode.Is(unicode.Hebrew, char):
+		return "hebr"
+	case unicode.Is(unicode.Cyrillic, char):
+		return "cyrl"
+	case unicode.Is(unicode.Greek, char):
+		return "grek"
+	case unicode.Is(unicode.Latin, char):
+		return "latn"
+	}
+	return "DFLT"
+}
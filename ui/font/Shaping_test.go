@@ -0,0 +1,130 @@
+package font
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func GlyphOffsets(glyphs []PositionedGlyph) []int {
+	var offsets []int
+	for _, glyph := range glyphs {
+		offsets = append(offsets, glyph.Offset)
+	}
+	return offsets
+}
+
+func TestShapeBidi(t *testing.T) {
+	fnt, err := LoadFont("testdata/DejaVuSansMono.ttf", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		str      string
+		expected []int
+	}{
+		{"abc def", []int{0, 1, 2, 3, 4, 5, 6}},
+		// right to left text within left to right text is reversed
+		{"ab אבג cd", []int{0, 1, 2, 7, 5, 3, 9, 10, 11}},
+		// numbers within right to left text stay left to right
+		{"אב 12 ג", []int{8, 7, 5, 6, 4, 2, 0}},
+	} {
+		if offsets := GlyphOffsets(ShapeText(fnt, test.str).Glyphs); !reflect.DeepEqual(offsets, test.expected) {
+			t.Errorf("Invalid order for %q: expected %v, got %v", test.str, test.expected, offsets)
+		}
+	}
+
+	glyphs := ShapeText(fnt, "(א)").Glyphs
+	if glyphs[0].Offset != 3 || glyphs[0].Index != fnt.Font.Index('(') || glyphs[2].Index != fnt.Font.Index(')') {
+		t.Error("Expected brackets in right to left text to be mirrored")
+	}
+}
+
+func TestShapeArabic(t *testing.T) {
+	fnt, err := LoadFont("testdata/DejaVuSansMono.ttf", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// seen, lam, alef, meem
+	str := "سلام"
+	shaped := ShapeText(fnt, str)
+	if offsets := GlyphOffsets(shaped.Glyphs); !reflect.DeepEqual(offsets, []int{6, 2, 0}) {
+		t.Fatalf("Expected meem, lam alef and seen from left to right, got %v", offsets)
+	}
+	// meem follows alef, which doesn't join to the letter after it, so only seen and lam alef change
+	for _, glyph := range shaped.Glyphs[1:] {
+		char := []rune(str[glyph.Offset:])[0]
+		if glyph.Index == fnt.Font.Index(char) {
+			t.Errorf("Expected %U to be replaced by a joining form or ligature", char)
+		}
+	}
+
+	// a letter on its own keeps its isolated form, while a run of them joins
+	beh := "ب"
+	if glyph := ShapeText(fnt, beh).Glyphs[0]; glyph.Index != fnt.Font.Index('ب') {
+		t.Error("Expected a letter on its own not to be replaced")
+	}
+	forms := ShapeText(fnt, beh+beh+beh).Glyphs
+	if forms[0].Index == forms[1].Index || forms[1].Index == forms[2].Index || forms[0].Index == forms[2].Index {
+		t.Error("Expected the final, medial and initial forms to be different glyphs")
+	}
+
+	// fonts without substitutions draw one glyph for each rune
+	regular := LoadTestFont(t, 20)
+	if glyphs := ShapeText(regular, "fi").Glyphs; len(glyphs) != 2 {
+		t.Errorf("Expected 2 glyphs without a ligature, got %d", len(glyphs))
+	}
+}
+
+func TestShapeMarks(t *testing.T) {
+	fnt := LoadTestFont(t, 20)
+	shaped := ShapeText(fnt, "éx")
+	if len(shaped.Glyphs) != 3 {
+		t.Fatalf("Expected a glyph for each rune, got %d", len(shaped.Glyphs))
+	}
+	e, x := shaped.Glyphs[0], shaped.Glyphs[2]
+	expected := fnt.GetGlyph(e.Index).AdvanceWidth + toPixels(fnt.Font.Kern(fnt.Size, e.Index, x.Index, fnt.Options.Hinting))
+	if math.Abs(x.X-expected) > 1e-9 {
+		t.Errorf("Expected the mark not to move the pen, got %f instead of %f", x.X, expected)
+	}
+	mark := shaped.Glyphs[1]
+	center := mark.X + fnt.GetGlyph(mark.Index).AdvanceWidth/2
+	if math.Abs(center-(e.X+fnt.GetGlyph(e.Index).AdvanceWidth/2)) > 1e-9 {
+		t.Errorf("Expected the mark to be centered on the e, got %f", mark.X)
+	}
+	if len(shaped.Carets) != 3 || shaped.Carets[1].Offset != 3 {
+		t.Errorf("Expected the cursor not to stop between a letter and its mark, got %v", shaped.Carets)
+	}
+}
+
+func TestCarets(t *testing.T) {
+	fnt, err := LoadFont("testdata/DejaVuSansMono.ttf", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := fnt.GetAdvance("a")
+
+	shaped := ShapeText(fnt, "ab")
+	if !reflect.DeepEqual(shaped.Carets, []Caret{{0, 0}, {1, cell}, {2, 2 * cell}}) {
+		t.Errorf("Invalid carets for left to right text: %v", shaped.Carets)
+	}
+
+	// carets run from right to left through right to left text
+	shaped = ShapeText(fnt, "אב")
+	if !reflect.DeepEqual(shaped.Carets, []Caret{{0, 2 * cell}, {2, cell}, {4, 0}}) {
+		t.Errorf("Invalid carets for right to left text: %v", shaped.Carets)
+	}
+	if shaped.GetCaretX(2) != cell || shaped.GetOffsetAt(2*cell-1) != 0 || shaped.GetOffsetAt(-5) != 4 {
+		t.Error("Expected carets to be found by offset and by position")
+	}
+
+	// the letters of a ligature split it between them
+	shaped = ShapeText(fnt, "لا")
+	if len(shaped.Glyphs) != 1 || !reflect.DeepEqual(shaped.Carets, []Caret{{0, cell}, {2, cell / 2}, {4, 0}}) {
+		t.Errorf("Invalid carets for a ligature: %v", shaped.Carets)
+	}
+
+	if shaped = ShapeText(fnt, ""); !reflect.DeepEqual(shaped.Carets, []Caret{{0, 0}}) {
+		t.Errorf("Expected a single caret for an empty string, got %v", shaped.Carets)
+	}
+}
@@ -0,0 +1,119 @@
+package font
+
+import (
+	"math"
+	"sort"
+)
+
+// tessellateEpsilon is the smallest distance between two y coordinates that is treated as a gap
+const tessellateEpsilon = 1e-9
+
+// tessellateEdge is a non-horizontal edge of a contour with its endpoints ordered from top to bottom
+type tessellateEdge struct {
+	x0, y0  float64
+	x1, y1  float64
+	winding int
+}
+
+// xAt finds where the edge crosses the horizontal line at y
+func (edge tessellateEdge) xAt(y float64) float64 {
+	return edge.x0 + (edge.x1-edge.x0)*(y-edge.y0)/(edge.y1-edge.y0)
+}
+
+// Tessellate converts a set of closed contours into a list of triangles, three points per triangle,
+// that cover every area the contours enclose according to the nonzero winding rule. Contours may
+// overlap, intersect and contain holes. All of the triangles are wound in the same direction.
+func Tessellate(contours [][]Point) []Point {
+	var edges []tessellateEdge
+	var ys []float64
+	for _, contour := range contours {
+		for i := range contour {
+			a := contour[i]
+			b := contour[(i+1)%len(contour)]
+			ys = append(ys, a.Y)
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, tessellateEdge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, tessellateEdge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+
+	// split the outline into horizontal slabs at every vertex and every place two edges cross, so
+	// that within a slab the edges never cross and can be ordered from left to right
+	for i := range edges {
+		for j := i + 1; j < len(edges); j++ {
+			if y, ok := intersectEdges(edges[i], edges[j]); ok {
+				ys = append(ys, y)
+			}
+		}
+	}
+	sort.Float64s(ys)
+
+	var triangles []Point
+	type crossing struct {
+		top, middle, bottom float64
+		winding             int
+	}
+	var crossings []crossing
+	for i := 1; i < len(ys); i++ {
+		top, bottom := ys[i-1], ys[i]
+		if bottom-top < tessellateEpsilon {
+			continue
+		}
+		middle := (top + bottom) / 2
+		crossings = crossings[:0]
+		for _, edge := range edges {
+			if edge.y0 <= middle && edge.y1 >= middle {
+				crossings = append(crossings, crossing{edge.xAt(top), edge.xAt(middle), edge.xAt(bottom), edge.winding})
+			}
+		}
+		sort.Slice(crossings, func(a, b int) bool {
+			return crossings[a].middle < crossings[b].middle
+		})
+
+		// fill between the crossings where the winding number is nonzero
+		winding := 0
+		var left crossing
+		for _, c := range crossings {
+			if winding == 0 {
+				left = c
+			}
+			winding += c.winding
+			if winding == 0 {
+				triangles = appendTrapezoid(triangles, top, left.top, c.top, bottom, left.bottom, c.bottom)
+			}
+		}
+	}
+	return triangles
+}
+
+// intersectEdges finds the y coordinate where two edges cross, if they cross away from their endpoints
+func intersectEdges(a tessellateEdge, b tessellateEdge) (float64, bool) {
+	top := math.Max(a.y0, b.y0)
+	bottom := math.Min(a.y1, b.y1)
+	if bottom-top < tessellateEpsilon {
+		return 0, false
+	}
+	dTop := a.xAt(top) - b.xAt(top)
+	dBottom := a.xAt(bottom) - b.xAt(bottom)
+	if dTop*dBottom >= 0 {
+		return 0, false
+	}
+	return top + (bottom-top)*dTop/(dTop-dBottom), true
+}
+
+// appendTrapezoid adds the triangles for a trapezoid with horizontal top and bottom sides, skipping
+// any triangles that have collapsed to nothing
+func appendTrapezoid(triangles []Point, top, topLeft, topRight, bottom, bottomLeft, bottomRight float64) []Point {
+	if topRight-topLeft > tessellateEpsilon {
+		triangles = append(triangles, Point{topLeft, top}, Point{topRight, top}, Point{bottomLeft, bottom})
+	}
+	if bottomRight-bottomLeft > tessellateEpsilon {
+		triangles = append(triangles, Point{topRight, top}, Point{bottomRight, bottom}, Point{bottomLeft, bottom})
+	}
+	return triangles
+}
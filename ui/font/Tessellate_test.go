@@ -0,0 +1,74 @@
+package font
+
+import (
+	"math"
+	"testing"
+)
+
+func TriangleArea(triangles []Point) float64 {
+	area := 0.0
+	for i := 0; i+2 < len(triangles); i += 3 {
+		a, b, c := triangles[i], triangles[i+1], triangles[i+2]
+		area += ((b.X-a.X)*(c.Y-a.Y) - (c.X-a.X)*(b.Y-a.Y)) / 2
+	}
+	return area
+}
+
+func Square(x, y, size float64, clockwise bool) []Point {
+	if clockwise {
+		return []Point{{x, y}, {x, y + size}, {x + size, y + size}, {x + size, y}}
+	}
+	return []Point{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}
+}
+
+func CheckArea(t *testing.T, triangles []Point, expected float64) {
+	if area := TriangleArea(triangles); math.Abs(area-expected) > 1e-6 {
+		t.Errorf("Invalid area: expected %f, got %f", expected, area)
+	}
+}
+
+func TestTessellateHole(t *testing.T) {
+	triangles := Tessellate([][]Point{Square(0, 0, 10, false), Square(2, 2, 6, true)})
+	CheckArea(t, triangles, 64)
+}
+
+func TestTessellateOverlap(t *testing.T) {
+	// two squares wound the same way overlap rather than cancel out under the nonzero rule
+	triangles := Tessellate([][]Point{Square(0, 0, 10, false), Square(5, 5, 10, false)})
+	CheckArea(t, triangles, 175)
+}
+
+func TestTessellateCrossing(t *testing.T) {
+	// a bow tie crosses itself in the middle
+	triangles := Tessellate([][]Point{{{0, 0}, {10, 10}, {10, 0}, {0, 10}}})
+	CheckArea(t, triangles, 50)
+}
+
+func TestTessellateGlyph(t *testing.T) {
+	fnt := LoadTestFont(t, 64)
+	for _, char := range "oA8" {
+		glyph := fnt.GetGlyph(fnt.Font.Index(char))
+		contours := glyph.GetContours()
+		if len(contours) < 2 {
+			t.Errorf("Expected %q to have a hole", char)
+		}
+		// the contours of these glyphs don't overlap, so holes take exactly their own area away
+		expected := 0.0
+		for _, contour := range contours {
+			expected += contourArea(contour)
+		}
+		expected = math.Abs(expected)
+		if area := TriangleArea(glyph.GetTriangles()); math.Abs(area-expected) > 1e-6*expected {
+			t.Errorf("Invalid area for %q: expected %f, got %f", char, expected, area)
+		}
+	}
+}
+
+func contourArea(contour []Point) float64 {
+	area := 0.0
+	for i := range contour {
+		a, b := contour[i], contour[(i+1)%len(contour)]
+		area += (a.X*b.Y - b.X*a.Y) / 2
+	}
+	return area
+}
@@ -0,0 +1,132 @@
+package font
+
+import (
+	"math"
+)
+
+// TextEffects decorate text with lines and surround it with a shadow, an outline and a glow. Glows are only
+// drawn around text from distance field fonts, and outlines and glows around distance field text can't
+// reach further from the glyph outlines than the spread of the font's distance fields.
+type TextEffects struct {
+	// Underline and Strikethrough draw lines under and through the text, placed using the font's metrics
+	Underline     bool
+	Strikethrough bool
+	// OutlineWidth is how far the outline extends outside the glyphs and lines, or 0 for no outline
+	OutlineWidth float64
+	OutlineColor [4]float32
+	// GlowRadius is how far the glow fades out over outside the glyphs, or 0 for no glow
+	GlowRadius float64
+	GlowColor  [4]float32
+	// ShadowOffset is how far the shadow is drawn from the text, or zero for no shadow. The shadow has the
+	// shape of the text along with its outline.
+	ShadowOffset Point
+	ShadowColor  [4]float32
+}
+
+// TextLayer is one pass of drawing text, which fills every point within Offset outside the glyph outlines
+// with a color and then fades out over the following Falloff
+type TextLayer struct {
+	Color   [4]float32
+	Offset  float64
+	Falloff float64
+	// X and Y move the whole layer away from the text
+	X float64
+	Y float64
+}
+
+// Layers gets the passes needed to draw text with the effects, in the order they should be drawn
+func (effects TextEffects) Layers(color [4]float32) []TextLayer {
+	var layers []TextLayer
+	if effects.ShadowOffset != (Point{}) {
+		layers = append(layers, TextLayer{
+			Color:  effects.ShadowColor,
+			Offset: math.Max(0, effects.OutlineWidth),
+			X:      effects.ShadowOffset.X,
+			Y:      effects.ShadowOffset.Y,
+		})
+	}
+	if effects.GlowRadius > 0 {
+		layers = append(layers, TextLayer{Color: effects.GlowColor, Falloff: effects.GlowRadius})
+	}
+	if effects.OutlineWidth > 0 {
+		layers = append(layers, TextLayer{Color: effects.OutlineColor, Offset: effects.OutlineWidth})
+	}
+	return append(layers, TextLayer{Color: color})
+}
+
+// Lines gets the rectangles of the underlines and strikethroughs of positioned glyphs, relative to the
+// position the glyphs are drawn at. Each baseline gets its own lines, running from the first glyph on it to
+// the end of the last.
+func (effects TextEffects) Lines(fnt *LoadedFont, glyphs []PositionedGlyph) []Bounds {
+	if !effects.Underline && !effects.Strikethrough {
+		return nil
+	}
+	var lines []Bounds
+	for start := 0; start < len(glyphs); {
+		baseline := glyphs[start].Y
+		left, right := math.Inf(1), math.Inf(-1)
+		end := start
+		for ; end < len(glyphs) && glyphs[end].Y == baseline; end++ {
+			glyphFont := glyphs[end].Font
+			if glyphFont == nil {
+				glyphFont = fnt
+			}
+			left = math.Min(left, glyphs[end].X)
+			right = math.Max(right, glyphs[end].X+glyphFont.GetGlyph(glyphs[end].Index).AdvanceWidth)
+		}
+		if effects.Underline {
+			offset, thickness := fnt.GetUnderline()
+			lines = append(lines, Bounds{X: left, Y: baseline + offset, Width: right - left, Height: thickness})
+		}
+		if effects.Strikethrough {
+			offset, thickness := fnt.GetStrikethrough()
+			lines = append(lines, Bounds{X: left, Y: baseline + offset, Width: right - left, Height: thickness})
+		}
+		start = end
+	}
+	return lines
+}
+
+// Coverage determines how much of a point the layer covers given how far inside the glyph outline the
+// point is. Layers with no falloff are anti-aliased over smoothing pixels either side of their edge,
+// which should be about half a pixel on the screen.
+func (layer TextLayer) Coverage(distance float64, smoothing float64) float64 {
+	distance += layer.Offset
+	if layer.Falloff > 0 {
+		return math.Max(0, math.Min(1, 1+distance/layer.Falloff))
+	}
+	t := math.Max(0, math.Min(1, (distance+smoothing)/(2*smoothing)))
+	return t * t * (3 - 2*t)
+}
+
+// Stamps gets the positions that glyphs without distance fields are drawn at for the layer, relative to
+// where the text is drawn. Those glyphs can't be grown from their outlines, so they are grown by the layer's
+// offset by drawing them at points spread over a disc instead. Layers that fade out are skipped entirely.
+func (layer TextLayer) Stamps() []Point {
+	if layer.Falloff > 0 {
+		return nil
+	}
+	stamps := []Point{{X: layer.X, Y: layer.Y}}
+	// one ring for every pixel of offset, with points close enough together that no gaps open between them
+	rings := int(math.Ceil(layer.Offset))
+	for ring := 1; ring <= rings; ring++ {
+		radius := layer.Offset * float64(ring) / float64(rings)
+		count := int(math.Max(8, math.Ceil(2*math.Pi*radius)))
+		for i := 0; i < count; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(count)
+			stamps = append(stamps, Point{X: layer.X + radius*math.Cos(angle), Y: layer.Y + radius*math.Sin(angle)})
+		}
+	}
+	return stamps
+}
+
+// Grow moves a rectangle, like one of the lines from TextEffects.Lines, with the layer and grows it by the
+// layer's offset on every side
+func (layer TextLayer) Grow(bounds Bounds) Bounds {
+	return Bounds{
+		X:      bounds.X + layer.X - layer.Offset,
+		Y:      bounds.Y + layer.Y - layer.Offset,
+		Width:  bounds.Width + 2*layer.Offset,
+		Height: bounds.Height + 2*layer.Offset,
+	}
+}
@@ -0,0 +1,91 @@
+package font
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTextEffects(t *testing.T) {
+	color := [4]float32{1, 1, 1, 1}
+	if layers := (TextEffects{}).Layers(color); len(layers) != 1 || layers[0].Color != color {
+		t.Error("Expected text without effects to only be filled")
+	}
+	layers := TextEffects{OutlineWidth: 2, GlowRadius: 4}.Layers(color)
+	if len(layers) != 3 || layers[0].Falloff != 4 || layers[1].Offset != 2 || layers[2].Color != color {
+		t.Fatalf("Expected a glow, an outline and then the fill, got %v", layers)
+	}
+	for _, test := range []struct {
+		layer    int
+		distance float64
+		coverage float64
+	}{
+		{0, 1, 1},
+		{0, -2, 0.5},
+		{0, -5, 0},
+		{1, -1.5, 1},
+		{1, -2, 0.5},
+		{1, -3, 0},
+		{2, 0, 0.5},
+		{2, 1, 1},
+		{2, -1, 0},
+	} {
+		if coverage := layers[test.layer].Coverage(test.distance, 0.5); coverage != test.coverage {
+			t.Errorf("Invalid coverage for layer %d at %.1f: expected %.1f, got %.2f", test.layer, test.distance, test.coverage, coverage)
+		}
+	}
+
+	shadow := TextEffects{OutlineWidth: 2, ShadowOffset: Point{X: 1, Y: 3}}.Layers(color)[0]
+	if shadow.X != 1 || shadow.Y != 3 || shadow.Offset != 2 {
+		t.Errorf("Expected the shadow to be drawn first with the outline's shape, got %v", shadow)
+	}
+}
+
+func TestStamps(t *testing.T) {
+	if stamps := (TextLayer{X: 1, Y: 2}).Stamps(); len(stamps) != 1 || stamps[0] != (Point{X: 1, Y: 2}) {
+		t.Errorf("Expected a layer without an offset to be drawn once, got %v", stamps)
+	}
+	if stamps := (TextLayer{Falloff: 2}).Stamps(); len(stamps) != 0 {
+		t.Error("Expected glows not to be drawn without distance fields")
+	}
+	stamps := TextLayer{Offset: 2}.Stamps()
+	if len(stamps) < 16 {
+		t.Errorf("Expected enough stamps to cover the outline, got %d", len(stamps))
+	}
+	for _, stamp := range stamps {
+		if math.Hypot(stamp.X, stamp.Y) > 2+1e-9 {
+			t.Errorf("Expected stamps to be within the offset, got %v", stamp)
+		}
+	}
+}
+
+func TestTextLines(t *testing.T) {
+	fnt := LoadTestFont(t, 32)
+	// the underline and strikeout metrics from the post and OS/2 tables are 275 and 50, and 512 and 102 units
+	scale := 32.0 / 2048
+	if offset, thickness := fnt.GetUnderline(); offset != math.Round(275*scale) || thickness != math.Round(50*scale) {
+		t.Errorf("Invalid underline: %f, %f", offset, thickness)
+	}
+	if offset, thickness := fnt.GetStrikethrough(); offset != -math.Round(512*scale) || thickness != math.Round(102*scale) {
+		t.Errorf("Invalid strikethrough: %f, %f", offset, thickness)
+	}
+
+	if lines := (TextEffects{}).Lines(fnt, nil); lines != nil {
+		t.Error("Expected no lines without underlines or strikethroughs")
+	}
+	layout := LayoutText(fnt, "Mars\nDecimation", LayoutOptions{})
+	var glyphs []PositionedGlyph
+	for _, line := range layout.Lines {
+		glyphs = append(glyphs, line.Glyphs...)
+	}
+	lines := TextEffects{Underline: true, Strikethrough: true}.Lines(fnt, glyphs)
+	if len(lines) != 4 {
+		t.Fatalf("Expected an underline and a strikethrough on each line, got %v", lines)
+	}
+	offset, _ := fnt.GetUnderline()
+	if lines[0].Y != layout.Lines[0].Glyphs[0].Y+offset || lines[2].Y != layout.Lines[1].Glyphs[0].Y+offset {
+		t.Errorf("Expected the underlines to follow the baselines, got %v", lines)
+	}
+	if math.Abs(lines[0].Width-fnt.GetAdvance("Mars")) > 1e-9 || math.Abs(lines[2].Width-fnt.GetAdvance("Decimation")) > 1e-9 {
+		t.Errorf("Expected the lines to span the text, got %v", lines)
+	}
+}
@@ -0,0 +1,265 @@
+package font
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextAlignment describes how lines of text are placed horizontally
+type TextAlignment int
+
+const (
+	// AlignLeft places each line against the left edge
+	AlignLeft TextAlignment = 0
+	// AlignCenter places each line halfway between the left and right edges
+	AlignCenter TextAlignment = 1
+	// AlignRight places each line against the right edge
+	AlignRight TextAlignment = 2
+	// AlignJustify stretches the spaces in each line so it reaches both edges, except for the last line of each paragraph
+	AlignJustify TextAlignment = 3
+)
+
+// LayoutOptions describes how a block of text is laid out
+type LayoutOptions struct {
+	// MaxWidth is the width lines are wrapped to fit in, or zero to only break lines at newlines
+	MaxWidth float64
+	// Alignment is how each line is placed horizontally
+	Alignment TextAlignment
+	// LineSpacing is the distance between baselines as a multiple of the font's line height, or zero for single spacing
+	LineSpacing float64
+}
+
+// TextLine is a single line of laid out text
+type TextLine struct {
+	// Start and End are the byte offsets of the line in the source string, not including the newline that ended it
+	Start int
+	End   int
+	// Glyphs are positioned relative to the top left corner of the layout
+	Glyphs []PositionedGlyph
+	// Carets are where a caret can be placed along the line in logical order, with byte offsets in the source
+	// string and positions relative to the left edge of the layout
+	Carets []Caret
+	// X is where the line starts once it has been aligned
+	X float64
+	// Baseline is the distance from the top of the layout to the baseline of the line
+	Baseline float64
+	// Width is the width of the line, not including trailing spaces
+	Width float64
+}
+
+// TextLayout is a block of text broken into lines
+type TextLayout struct {
+	Text   string
+	Lines  []TextLine
+	Width  float64
+	Height float64
+}
+
+// LayoutText breaks text into lines at newlines and, when there is a maximum width, wherever else a line
+// is allowed to break so each line fits. Words too long to fit on a line by themselves are split.
+func LayoutText(font *LoadedFont, str string, options LayoutOptions) *TextLayout {
+	layout := &TextLayout{
+		Text: str,
+	}
+	lineSpacing := options.LineSpacing
+	if lineSpacing == 0 {
+		lineSpacing = 1
+	}
+
+	advances := advanceTable(font, str)
+	spans := breakLines(str, options.MaxWidth, func(start, end int) float64 {
+		end = start + len(strings.TrimRight(trimLineEnd(str[start:end]), " \t"))
+		return advances[end] - advances[start]
+	})
+
+	// position the glyphs of each line
+	lineHeight := font.GetLineHeight()
+	for i, s := range spans {
+		text := trimLineEnd(str[s.start:s.end])
+		shaped := ShapeText(font, text)
+		baseline := font.GetAscent() + float64(i)*lineHeight*lineSpacing
+		// spaces at the end of a line hang past it rather than counting towards its width
+		width := shaped.Advance
+		trimmed := len(strings.TrimRight(text, " \t"))
+		for g, glyph := range shaped.Glyphs {
+			if glyph.Offset >= trimmed {
+				width -= shaped.glyphAdvance(g)
+			}
+		}
+		for g := range shaped.Glyphs {
+			shaped.Glyphs[g].Y = baseline
+			shaped.Glyphs[g].Offset += s.start
+		}
+		for c := range shaped.Carets {
+			shaped.Carets[c].Offset += s.start
+		}
+		line := TextLine{
+			Start:    s.start,
+			End:      s.start + len(text),
+			Glyphs:   shaped.Glyphs,
+			Carets:   shaped.Carets,
+			Baseline: baseline,
+			Width:    width,
+		}
+		layout.Lines = append(layout.Lines, line)
+		layout.Width = math.Max(layout.Width, line.Width)
+	}
+	if len(layout.Lines) > 0 {
+		last := layout.Lines[len(layout.Lines)-1]
+		layout.Height = last.Baseline + font.GetDescent()
+	}
+
+	// align each line within the maximum width, or within the widest line if there isn't one
+	width := layout.Width
+	if options.MaxWidth > 0 {
+		width = options.MaxWidth
+	}
+	for i := range layout.Lines {
+		line := &layout.Lines[i]
+		switch options.Alignment {
+		case AlignCenter:
+			line.X = (width - line.Width) / 2
+		case AlignRight:
+			line.X = width - line.Width
+		case AlignJustify:
+			if !spans[i].paragraph {
+				justifyLine(layout.Text, line, width)
+			}
+		}
+		for g := range line.Glyphs {
+			line.Glyphs[g].X += line.X
+		}
+		for c := range line.Carets {
+			line.Carets[c].X += line.X
+		}
+	}
+	return layout
+}
+
+// lineSpan is where a line starts and ends in a string, and whether it ends a paragraph
+type lineSpan struct {
+	start, end int
+	paragraph  bool
+}
+
+// advanceTable shapes each paragraph of a string once and finds, for every byte offset, how far the pen has
+// moved by the glyphs of the runes before it. The width of any part of the string is then the difference
+// between the advances at its ends, which ignores kerning and ligatures across its ends but saves shaping
+// the text again for every place a line could break.
+func advanceTable(font *LoadedFont, str string) []float64 {
+	advances := make([]float64, len(str)+1)
+	start := 0
+	breaks := append(FindLineBreaks(str), LineBreak{Offset: len(str), Mandatory: true})
+	for _, lineBreak := range breaks {
+		if !lineBreak.Mandatory {
+			continue
+		}
+		shaped := ShapeText(font, trimLineEnd(str[start:lineBreak.Offset]))
+		for g, glyph := range shaped.Glyphs {
+			advances[start+glyph.Offset+1] += shaped.glyphAdvance(g)
+		}
+		start = lineBreak.Offset
+	}
+	for i := 1; i < len(advances); i++ {
+		advances[i] += advances[i-1]
+	}
+	return advances
+}
+
+// glyphAdvance finds how far a shaped glyph moves the pen. Glyphs are in the order they are drawn, so each
+// one reaches up to where the next one starts.
+func (shaped ShapedText) glyphAdvance(g int) float64 {
+	if g+1 < len(shaped.Glyphs) {
+		return shaped.Glyphs[g+1].X - shaped.Glyphs[g].X
+	}
+	return shaped.Advance - shaped.Glyphs[g].X
+}
+
+// breakLines finds where each line of a string starts and ends. Lines break at newlines and, when there is
+// a maximum width, wherever else a line is allowed to break so that measure, which gives the width of the
+// text between two byte offsets, fits within it. Words too long to fit on a line by themselves are split.
+// A string that ends with a newline ends with an empty line.
+func breakLines(str string, maxWidth float64, measure func(start, end int) float64) []lineSpan {
+	var spans []lineSpan
+	lineStart := 0
+	lastFit := -1
+	breaks := append(FindLineBreaks(str), LineBreak{Offset: len(str), Mandatory: true})
+	for _, lineBreak := range breaks {
+		for maxWidth > 0 && lineStart < lineBreak.Offset && measure(lineStart, lineBreak.Offset) > maxWidth {
+			if lastFit > lineStart {
+				spans = append(spans, lineSpan{lineStart, lastFit, false})
+				lineStart = lastFit
+				lastFit = -1
+				continue
+			}
+			// nowhere to break, so split the word at the last rune that fits, keeping at least one rune
+			split := lineStart
+			for offset, char := range str[lineStart:lineBreak.Offset] {
+				next := lineStart + offset + utf8.RuneLen(char)
+				if split > lineStart && measure(lineStart, next) > maxWidth {
+					break
+				}
+				split = next
+			}
+			if split >= lineBreak.Offset {
+				break
+			}
+			spans = append(spans, lineSpan{lineStart, split, false})
+			lineStart = split
+		}
+		if lineBreak.Mandatory {
+			spans = append(spans, lineSpan{lineStart, lineBreak.Offset, true})
+			lineStart = lineBreak.Offset
+			lastFit = -1
+		} else {
+			lastFit = lineBreak.Offset
+		}
+	}
+	if last, _ := utf8.DecodeLastRuneInString(str); len(str) > 0 && isLineEnd(last) {
+		spans = append(spans, lineSpan{len(str), len(str), true})
+	}
+	return spans
+}
+
+// justifyLine spreads the extra space at the end of a line between the spaces inside it
+func justifyLine(str string, line *TextLine, width float64) {
+	spaces := 0
+	for _, glyph := range line.Glyphs {
+		if glyph.X < line.Width && str[glyph.Offset] == ' ' {
+			spaces++
+		}
+	}
+	if spaces == 0 {
+		return
+	}
+	extra := (width - line.Width) / float64(spaces)
+	shift := 0.0
+	var stretched []float64
+	for g := range line.Glyphs {
+		line.Glyphs[g].X += shift
+		if line.Glyphs[g].X-shift < line.Width && str[line.Glyphs[g].Offset] == ' ' {
+			stretched = append(stretched, line.Glyphs[g].X-shift)
+			shift += extra
+		}
+	}
+	// carets move along with the glyphs after them, so a caret after a space stays after it once it's wider
+	for c := range line.Carets {
+		x := line.Carets[c].X
+		for _, start := range stretched {
+			if start < x {
+				line.Carets[c].X += extra
+			}
+		}
+	}
+	line.Width = width
+}
+
+// GetGlyphs gets the glyphs of every line in the layout
+func (layout *TextLayout) GetGlyphs() []PositionedGlyph {
+	var glyphs []PositionedGlyph
+	for _, line := range layout.Lines {
+		glyphs = append(glyphs, line.Glyphs...)
+	}
+	return glyphs
+}
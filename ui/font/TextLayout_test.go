@@ -0,0 +1,131 @@
+package font
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func CheckBreaks(t *testing.T, str string, expected []LineBreak) {
+	if breaks := FindLineBreaks(str); !reflect.DeepEqual(breaks, expected) {
+		t.Errorf("Invalid line breaks for %q: expected %v, got %v", str, expected, breaks)
+	}
+}
+
+func TestLineBreaks(t *testing.T) {
+	CheckBreaks(t, "two words", []LineBreak{{4, false}})
+	CheckBreaks(t, "one\ntwo", []LineBreak{{4, true}})
+	CheckBreaks(t, "crlf\r\nline", []LineBreak{{6, true}})
+	CheckBreaks(t, "spaces   here", []LineBreak{{9, false}})
+	CheckBreaks(t, "well-known", []LineBreak{{5, false}})
+	CheckBreaks(t, "x -5", []LineBreak{{2, false}})
+	CheckBreaks(t, "no\u00a0break", nil)
+	CheckBreaks(t, "(wait) what!", []LineBreak{{7, false}})
+	CheckBreaks(t, "火星", []LineBreak{{3, false}})
+	CheckBreaks(t, "zero\u200bwidth", []LineBreak{{7, false}})
+}
+
+func LineTexts(layout *TextLayout) []string {
+	var lines []string
+	for _, line := range layout.Lines {
+		lines = append(lines, layout.Text[line.Start:line.End])
+	}
+	return lines
+}
+
+func TestLayoutWrapping(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	width := fnt.GetAdvance("mission briefing")
+	layout := LayoutText(fnt, "mission briefing for the\nfirst landing", LayoutOptions{MaxWidth: width})
+	expected := []string{"mission briefing ", "for the", "first landing"}
+	if lines := LineTexts(layout); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Invalid lines: expected %q, got %q", expected, lines)
+	}
+	for i, line := range layout.Lines {
+		if line.Width > width {
+			t.Errorf("Line %d is wider than the maximum width", i)
+		}
+		if expected := fnt.GetAscent() + float64(i)*fnt.GetLineHeight(); math.Abs(line.Baseline-expected) > 1e-9 {
+			t.Errorf("Invalid baseline for line %d: expected %f, got %f", i, expected, line.Baseline)
+		}
+		for _, glyph := range line.Glyphs {
+			if glyph.Y != line.Baseline {
+				t.Errorf("Glyph is not on the baseline of line %d", i)
+			}
+		}
+	}
+
+	layout = LayoutText(fnt, "Decimation", LayoutOptions{MaxWidth: fnt.GetAdvance("Deci")})
+	expected = []string{"Deci", "mati", "on"}
+	if lines := LineTexts(layout); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Invalid lines for a long word: expected %q, got %q", expected, lines)
+	}
+
+	// a long unbroken string splits into lines that each fit, with nothing lost
+	long := strings.Repeat("x", 20000)
+	layout = LayoutText(fnt, long, LayoutOptions{MaxWidth: fnt.GetAdvance("xxxxxxxxxx")})
+	if len(layout.Lines) != 2000 || strings.Join(LineTexts(layout), "") != long {
+		t.Errorf("Expected the string to split into 2000 lines, got %d", len(layout.Lines))
+	}
+
+	// a trailing newline ends with an empty line
+	layout = LayoutText(fnt, "a\n", LayoutOptions{})
+	expected = []string{"a", ""}
+	if lines := LineTexts(layout); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Invalid lines for a trailing newline: expected %q, got %q", expected, lines)
+	}
+	if expected := fnt.GetAscent() + fnt.GetLineHeight() + fnt.GetDescent(); math.Abs(layout.Height-expected) > 1e-9 {
+		t.Errorf("Expected the empty line to add to the height, got %f", layout.Height)
+	}
+}
+
+func TestLayoutAlignment(t *testing.T) {
+	fnt := LoadTestFont(t, 16)
+	str := "red planet rising over the ridge"
+	width := fnt.GetAdvance("red planet rising") + 10
+	for _, alignment := range []TextAlignment{AlignLeft, AlignCenter, AlignRight, AlignJustify} {
+		layout := LayoutText(fnt, str, LayoutOptions{MaxWidth: width, Alignment: alignment, LineSpacing: 1.5})
+		if len(layout.Lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d", len(layout.Lines))
+		}
+		first, last := layout.Lines[0], layout.Lines[1]
+		right := first.X + first.Width
+		switch alignment {
+		case AlignLeft:
+			if first.X != 0 || last.X != 0 {
+				t.Error("Expected left aligned lines to start at zero")
+			}
+		case AlignCenter:
+			if math.Abs(first.X-(width-right)) > 1e-9 {
+				t.Error("Expected centered lines to have equal space on both sides")
+			}
+		case AlignRight:
+			if math.Abs(right-width) > 1e-9 || math.Abs(last.X+last.Width-width) > 1e-9 {
+				t.Error("Expected right aligned lines to end at the maximum width")
+			}
+		case AlignJustify:
+			end := first.Glyphs[len("red planet risin")]
+			if math.Abs(end.X+fnt.GetGlyph(end.Index).AdvanceWidth-width) > 1e-6 {
+				t.Error("Expected justified lines to end at the maximum width")
+			}
+			if last.Glyphs[4].X != fnt.GetAdvance("over") {
+				t.Error("Expected the last line of a paragraph not to be justified")
+			}
+			for _, caret := range first.Carets[:len(first.Carets)-1] {
+				if glyph := first.Glyphs[caret.Offset]; math.Abs(caret.X-glyph.X) > 1e-9 {
+					t.Errorf("Expected the caret at %d to move with the stretched spaces, got %f for a glyph at %f", caret.Offset, caret.X, glyph.X)
+				}
+			}
+		}
+		if caret := last.Carets[0]; caret.Offset != last.Start || caret.X != last.X {
+			t.Errorf("Expected the first caret of a line to be at its start, got %v", caret)
+		}
+		if caret := last.Carets[len(last.Carets)-1]; caret.Offset != last.End {
+			t.Errorf("Expected the last caret of a line to be at its end, got %v", caret)
+		}
+		if spacing := last.Baseline - first.Baseline; math.Abs(spacing-1.5*fnt.GetLineHeight()) > 1e-9 {
+			t.Errorf("Invalid line spacing %f", spacing)
+		}
+	}
+}
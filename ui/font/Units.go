@@ -0,0 +1,26 @@
+package font
+
+import (
+	"math"
+
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultDPI is the dots per inch that font sizes in points are converted with until told otherwise,
+// which makes a point the same size as a pixel
+const DefaultDPI = 72
+
+// PointsToPixels converts a font size in points into pixels per em on a screen with the given dots per inch
+func PointsToPixels(points float64, dpi float64) float64 {
+	return points * dpi / 72
+}
+
+// toPixels converts a 26.6 fixed point measurement, like a metric or a point on a glyph outline, into pixels
+func toPixels(value fixed.Int26_6) float64 {
+	return float64(value) / 64
+}
+
+// toFixed converts a measurement in pixels into 26.6 fixed point
+func toFixed(value float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(value * 64))
+}